@@ -2,45 +2,81 @@ package client
 
 import (
 	"encoding/hex"
+	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/ed25519"
-	"golang.org/x/crypto/sha3"
 
 	"github.com/the729/go-libra/types"
 )
 
-// MustToAddress converts hex string represent of an address into types.AccountAddress.
-// Input string should be a hex string with exactly 64 hex digits.
-func MustToAddress(str string) (out types.AccountAddress) {
+// ToAddress converts hex string represent of an address into types.AccountAddress.
+// Input string should be a hex string with exactly 32 hex digits.
+func ToAddress(str string) (out types.AccountAddress, err error) {
 	addr, err := hex.DecodeString(str)
 	if err != nil {
-		panic(err)
+		return out, err
 	}
 	if len(addr) != types.AccountAddressLength {
-		panic("wrong address length")
+		return out, fmt.Errorf("wrong address length %d", len(addr))
 	}
 	copy(out[:], addr)
-	return
+	return out, nil
 }
 
-// PubkeyMustToAddress converts an ed25519 public key (32 bytes) into types.AccountAddress (16 bytes).
-func PubkeyMustToAddress(pubkey []byte) (out types.AccountAddress) {
-	if len(pubkey) != ed25519.PublicKeySize {
-		panic("wrong pubkey length")
+// MustToAddress is like ToAddress, but panics on error.
+func MustToAddress(str string) types.AccountAddress {
+	out, err := ToAddress(str)
+	if err != nil {
+		panic(err)
 	}
-	hasher := sha3.New256()
-	hasher.Write(pubkey)
-	keyHash := hasher.Sum([]byte{})
-	copy(out[:], keyHash[hasher.Size()-types.AccountAddressLength:])
-	return
+	return out
 }
 
-// PubkeyMustToAuthKey converts an ed25519 public key (32 bytes) into auth key (32 bytes).
+// ParseAccount parses an account, which is either an address of exactly 32 hex digits, or
+// a bech32 account identifier with an optional subaddress. The network prefix of the
+// identifier is not checked.
+func ParseAccount(str string) (types.AccountAddress, types.SubAddress, error) {
+	if !strings.ContainsRune(str, '1') || len(str) == 2*types.AccountAddressLength {
+		addr, err := ToAddress(str)
+		return addr, types.SubAddress{}, err
+	}
+	id, err := types.ParseAccountIdentifier(str)
+	if err != nil {
+		return types.AccountAddress{}, types.SubAddress{}, err
+	}
+	return id.Address, id.SubAddress, nil
+}
+
+// PubkeyToAuthKey converts an ed25519 public key (32 bytes) into its authentication key.
+func PubkeyToAuthKey(pubkey []byte) (types.AuthenticationKey, error) {
+	return types.Ed25519AuthenticationKey(ed25519.PublicKey(pubkey))
+}
+
+// PubkeyToAddress converts an ed25519 public key (32 bytes) into the address derived
+// from its authentication key.
+func PubkeyToAddress(pubkey []byte) (types.AccountAddress, error) {
+	authKey, err := PubkeyToAuthKey(pubkey)
+	if err != nil {
+		return types.AccountAddress{}, err
+	}
+	return authKey.DerivedAddress(), nil
+}
+
+// PubkeyMustToAddress is like PubkeyToAddress, but panics on error.
+func PubkeyMustToAddress(pubkey []byte) types.AccountAddress {
+	out, err := PubkeyToAddress(pubkey)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// PubkeyMustToAuthKey is like PubkeyToAuthKey, but returns a byte slice, and panics on error.
 func PubkeyMustToAuthKey(pubkey []byte) []byte {
-	if len(pubkey) != ed25519.PublicKeySize {
-		panic("wrong pubkey length")
+	authKey, err := PubkeyToAuthKey(pubkey)
+	if err != nil {
+		panic(err)
 	}
-	hasher := sha3.New256()
-	hasher.Write(pubkey)
-	return hasher.Sum([]byte{})
+	return authKey[:]
 }
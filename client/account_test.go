@@ -0,0 +1,82 @@
+package client_test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/types"
+)
+
+func TestAuthKey(t *testing.T) {
+	pubkey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	// auth key is sha3(pubkey || scheme)
+	expected := sha3.Sum256(append(append([]byte{}, pubkey...), byte(types.Ed25519Scheme)))
+	authKey, err := client.PubkeyToAuthKey(pubkey)
+	require.NoError(t, err)
+	assert.Equal(t, expected[:], authKey[:])
+	assert.Equal(t, expected[:], client.PubkeyMustToAuthKey(pubkey))
+	assert.Equal(t, expected[:16], authKey.Prefix())
+
+	addr, err := client.PubkeyToAddress(pubkey)
+	require.NoError(t, err)
+	assert.Equal(t, authKey.DerivedAddress(), addr)
+	assert.Equal(t, expected[16:], addr[:])
+	assert.Equal(t, addr, client.MustToAddress(hex.EncodeToString(addr[:])))
+
+	_, err = client.PubkeyToAddress(pubkey[1:])
+	assert.Error(t, err)
+	_, err = client.ToAddress("00")
+	assert.Error(t, err)
+
+	_, err = client.NewRawP2PTransaction(addr, addr, authKey[:], 0, 1, 1, 0, time.Now())
+	assert.Error(t, err)
+}
+
+func TestAccountIdentifier(t *testing.T) {
+	addr := client.MustToAddress("f72589b71ff4f8d139674a3f7369c69b")
+	subAddr := types.SubAddress{0xcf, 0x64, 0x42, 0x8b, 0xde, 0xb6, 0x2a, 0xf2}
+
+	t.Run("encode", func(t *testing.T) {
+		id := &types.AccountIdentifier{Prefix: types.MainnetPrefix, Address: addr, SubAddress: subAddr}
+		assert.Equal(t, "lbr1p7ujcndcl7nudzwt8fglhx6wxn08kgs5tm6mz4usw5p72t", id.String())
+		id.SubAddress = types.SubAddress{}
+		assert.Equal(t, "lbr1p7ujcndcl7nudzwt8fglhx6wxnvqqqqqqqqqqqqqflf8ma", id.String())
+		id.Prefix = "btc"
+		_, err := id.Encode()
+		assert.Error(t, err)
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		id := &types.AccountIdentifier{Prefix: types.TestnetPrefix, Address: addr, SubAddress: subAddr}
+		parsed, err := types.ParseAccountIdentifier(id.String())
+		require.NoError(t, err)
+		assert.Equal(t, id, parsed)
+
+		a, s, err := client.ParseAccount(id.String())
+		require.NoError(t, err)
+		assert.Equal(t, addr, a)
+		assert.Equal(t, subAddr, s)
+
+		a, s, err = client.ParseAccount(hex.EncodeToString(addr[:]))
+		require.NoError(t, err)
+		assert.Equal(t, addr, a)
+		assert.True(t, s.IsZero())
+	})
+
+	t.Run("typo", func(t *testing.T) {
+		_, _, err := client.ParseAccount("lbr1p7ujcndcl7nudzwt8fglhx6wxn08kgs5tm6mz4usw5p72s")
+		assert.Error(t, err)
+		_, _, err = client.ParseAccount("lbr1p7ujcndcl7nudzwt8fglhx6wxn08kgs5tm6mz4usw5p7")
+		assert.Error(t, err)
+	})
+}
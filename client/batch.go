@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/types"
+)
+
+// Batch is a builder of batched queries. All queries in a batch are sent in one single
+// UpdateToLatestLedger request, and are verified against the same ledger info. Thus the
+// results are a consistent snapshot of the ledger.
+//
+// Add queries to a batch, call Execute, and then read results from the returned result
+// structs. Each result struct carries its own error, which should be checked.
+type Batch struct {
+	c        *Client
+	items    []*pbtypes.RequestItem
+	handlers []func(*pbtypes.ResponseItem, *types.ProvenLedgerInfo)
+}
+
+// BatchAccountState is the result of an account state query in a batch.
+type BatchAccountState struct {
+	State *types.ProvenAccountState
+	Err   error
+}
+
+// BatchSequenceNumber is the result of an account sequence number query in a batch.
+type BatchSequenceNumber struct {
+	SequenceNumber uint64
+	Err            error
+}
+
+// BatchTransaction is the result of a transaction query by account and sequence number in a batch.
+type BatchTransaction struct {
+	Transaction *types.ProvenTransaction
+	Err         error
+}
+
+// BatchTransactionList is the result of a transaction range query in a batch.
+type BatchTransactionList struct {
+	TransactionList *types.ProvenTransactionList
+	Err             error
+}
+
+// BatchEvents is the result of an events query in a batch.
+type BatchEvents struct {
+	Events    []*types.ProvenEvent
+	EventList *types.ProvenEventList
+	Err       error
+}
+
+var errBatchNotExecuted = errors.New("batch not executed")
+
+// NewBatch creates a new empty batch.
+func (c *Client) NewBatch() *Batch {
+	return &Batch{c: c}
+}
+
+// Len returns the number of queries in the batch.
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+func (b *Batch) add(item *pbtypes.RequestItem, handler func(*pbtypes.ResponseItem, *types.ProvenLedgerInfo)) {
+	b.items = append(b.items, item)
+	b.handlers = append(b.handlers, handler)
+}
+
+// QueryAccountState adds an account state query to the batch.
+func (b *Batch) QueryAccountState(addr types.AccountAddress) *BatchAccountState {
+	r := &BatchAccountState{Err: errBatchNotExecuted}
+	b.add(accountStateRequest(addr), func(item *pbtypes.ResponseItem, pli *types.ProvenLedgerInfo) {
+		r.State, r.Err = verifyAccountState(item, addr, pli)
+	})
+	return r
+}
+
+// QueryAccountSequenceNumber adds an account sequence number query to the batch.
+func (b *Batch) QueryAccountSequenceNumber(addr types.AccountAddress) *BatchSequenceNumber {
+	r := &BatchSequenceNumber{Err: errBatchNotExecuted}
+	b.add(accountStateRequest(addr), func(item *pbtypes.ResponseItem, pli *types.ProvenLedgerInfo) {
+		paccount, err := verifyAccountState(item, addr, pli)
+		if err != nil {
+			r.Err = err
+			return
+		}
+		r.SequenceNumber, r.Err = accountSequenceNumber(paccount)
+	})
+	return r
+}
+
+// QueryTransactionByAccountSeq adds a query of the transaction sent from a specific account at
+// a specific sequence number to the batch.
+func (b *Batch) QueryTransactionByAccountSeq(addr types.AccountAddress, sequence uint64, withEvents bool) *BatchTransaction {
+	r := &BatchTransaction{Err: errBatchNotExecuted}
+	b.add(transactionByAccountSeqRequest(addr, sequence, withEvents), func(item *pbtypes.ResponseItem, pli *types.ProvenLedgerInfo) {
+		r.Transaction, r.Err = verifyTransactionByAccountSeq(item, addr, sequence, withEvents, pli)
+	})
+	return r
+}
+
+// QueryTransactionRange adds a transaction range query to the batch.
+func (b *Batch) QueryTransactionRange(start, limit uint64, withEvents bool) *BatchTransactionList {
+	r := &BatchTransactionList{Err: errBatchNotExecuted}
+	b.add(transactionRangeRequest(start, limit, withEvents), func(item *pbtypes.ResponseItem, pli *types.ProvenLedgerInfo) {
+		r.TransactionList, r.Err = verifyTransactionRange(item, pli)
+	})
+	return r
+}
+
+// QueryEventsByAccessPath adds an events query by access path to the batch.
+func (b *Batch) QueryEventsByAccessPath(ap *types.AccessPath, start uint64, ascending bool, limit uint64) *BatchEvents {
+	r := &BatchEvents{Err: errBatchNotExecuted}
+	b.add(eventsByAccessPathRequest(ap, start, ascending, limit), func(item *pbtypes.ResponseItem, pli *types.ProvenLedgerInfo) {
+		r.EventList, r.Err = verifyEventsByAccessPath(item, ap, start, ascending, limit, pli)
+		if r.Err == nil {
+			r.Events = r.EventList.GetEvents()
+		}
+	})
+	return r
+}
+
+// Execute sends all queries in the batch in one request, verifies the ledger info, and then
+// verifies every response item against the ledger info.
+//
+// The returned error only reflects failures of the request or the ledger info. Errors of
+// individual queries are set in their result structs.
+func (b *Batch) Execute(ctx context.Context) (*types.ProvenLedgerInfo, error) {
+	resp, pli, err := b.c.updateToLatestLedger(ctx, b.items)
+	if err != nil {
+		return nil, err
+	}
+	for idx, handler := range b.handlers {
+		handler(resp.ResponseItems[idx], pli)
+	}
+	return pli, nil
+}
@@ -0,0 +1,82 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 50)
+	s, c := libratest.StartServer(t, l)
+
+	t.Run("all kinds", func(t *testing.T) {
+		b := c.NewBatch()
+		r1 := b.QueryAccountState(alice.Address)
+		r2 := b.QueryAccountState(bob.Address)
+		r3 := b.QueryAccountSequenceNumber(alice.Address)
+		r4 := b.QueryTransactionRange(0, 3, true)
+		r5 := b.QueryTransactionByAccountSeq(alice.Address, 0, false)
+		r6 := b.QueryEventsByAccessPath(&types.AccessPath{Address: bob.Address, Path: types.AccountReceivedEventPath()}, 0, true, 10)
+		assert.Equal(t, 6, b.Len())
+		assert.Error(t, r1.Err)
+
+		pli, err := b.Execute(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, r1.Err)
+		require.NoError(t, r2.Err)
+		assert.Equal(t, pli, r1.State.GetLedgerInfo())
+		assert.Equal(t, pli, r2.State.GetLedgerInfo())
+		_, br, err := r2.State.GetAccountBlob().GetLibraResources()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(50), br.Coin)
+
+		require.NoError(t, r3.Err)
+		assert.Equal(t, uint64(0), r3.SequenceNumber)
+		require.NoError(t, r4.Err)
+		assert.Len(t, r4.TransactionList.GetTransactions(), 3)
+		assert.Error(t, r5.Err)
+		require.NoError(t, r6.Err)
+		assert.Len(t, r6.EventList.GetEvents(), 0)
+		assert.Equal(t, uint64(0), r6.EventList.GetTotalCount())
+	})
+
+	t.Run("tampered item", func(t *testing.T) {
+		s.TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			blob := resp.ResponseItems[1].GetGetAccountStateResponse().AccountStateWithProof.Blob
+			blob.Blob[len(blob.Blob)-1] ^= 1
+		}
+		defer func() { s.TamperResponse = nil }()
+
+		b := c.NewBatch()
+		r1 := b.QueryAccountState(alice.Address)
+		r2 := b.QueryAccountState(bob.Address)
+		_, err := b.Execute(ctx)
+		require.NoError(t, err)
+		assert.NoError(t, r1.Err)
+		assert.Error(t, r2.Err)
+	})
+
+	t.Run("missing item", func(t *testing.T) {
+		s.TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			resp.ResponseItems = resp.ResponseItems[:1]
+		}
+		defer func() { s.TamperResponse = nil }()
+
+		b := c.NewBatch()
+		b.QueryAccountState(alice.Address)
+		b.QueryAccountState(bob.Address)
+		_, err := b.Execute(ctx)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/the729/go-libra/crypto/sha3libra"
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/types"
+	"github.com/the729/go-libra/types/proof/accumulator"
+)
+
+// needBootstrap returns the trusted waypoint, if the client starts from a waypoint other
+// than the genesis, and does not know the accumulator at the waypoint version yet.
+func (c *Client) needBootstrap() *types.Waypoint {
+	c.accMu.RLock()
+	defer c.accMu.RUnlock()
+	wp, ok := c.verifier.(*types.Waypoint)
+	if !ok || wp.Version == 0 || c.acc.FrozenSubtreeRoots != nil {
+		return nil
+	}
+	return wp
+}
+
+// bootstrap rebuilds the frozen subtree roots of the accumulator at the waypoint version,
+// so that the consistency of later ledger infos can be verified, without replaying the
+// history from the genesis.
+//
+// The frozen subtrees of an accumulator with N leaves are exactly the left siblings in the
+// accumulator proof of the leaf at index N, so the accumulator at the waypoint version is
+// rebuilt from the range proof of the transaction at that version, by appending the
+// transaction info. The result is trusted only if its root hash matches the one in the
+// ledger info of the waypoint.
+func (c *Client) bootstrap(ctx context.Context, wp *types.Waypoint) error {
+	req := &pbtypes.UpdateToLatestLedgerRequest{
+		ClientKnownVersion: wp.Version,
+		RequestedItems:     []*pbtypes.RequestItem{transactionRangeRequest(wp.Version, 1, false)},
+	}
+	var resp *pbtypes.UpdateToLatestLedgerResponse
+	err := c.withRetry(ctx, false, func(int) error {
+		return c.withFailover(ctx, func(ep *endpoint) error {
+			var err error
+			resp, err = ep.ac.UpdateToLatestLedger(ctx, req)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// the ledger info of the waypoint is the first one of the validator change proof
+	vcp := &types.ValidatorChangeProof{}
+	if err := vcp.FromProto(resp.ValidatorChangeProof); err != nil {
+		return fmt.Errorf("validator change proof invalid: %v", err)
+	}
+	if len(vcp.LedgerInfoWithSigs) == 0 {
+		return errors.New("waypoint ledger info not found")
+	}
+	li := vcp.LedgerInfoWithSigs[0]
+	if err := wp.Verify(li); err != nil {
+		return fmt.Errorf("waypoint ledger info verification failed: %v", err)
+	}
+	expectedRootHash := li.Value.(*types.LedgerInfoWithSignaturesV0).TransactionAccumulatorHash
+
+	if len(resp.ResponseItems) != 1 {
+		return fmt.Errorf("mismatch length: 1 requested items, %d response items", len(resp.ResponseItems))
+	}
+	txnList := &types.TransactionListWithProof{}
+	if err := txnList.FromProtoResponse(resp.ResponseItems[0].GetGetTransactionsResponse()); err != nil {
+		return fmt.Errorf("transaction list invalid: %v", err)
+	}
+	if len(txnList.Transactions) != 1 || txnList.Transactions[0].Version != wp.Version {
+		return fmt.Errorf("transaction of version %d not found", wp.Version)
+	}
+
+	// left siblings are from bottom to top, while frozen subtrees are from left to right
+	leftSiblings := cloneSubtrees(txnList.Proof.LeftSiblings)
+	for i, j := 0, len(leftSiblings)-1; i < j; i, j = i+1, j-1 {
+		leftSiblings[i], leftSiblings[j] = leftSiblings[j], leftSiblings[i]
+	}
+	acc := &accumulator.Accumulator{
+		Hasher:             sha3libra.NewTransactionAccumulator(),
+		FrozenSubtreeRoots: leftSiblings,
+		NumLeaves:          wp.Version,
+	}
+	if err := acc.AppendOne(txnList.Transactions[0].Info.Hash()); err != nil {
+		return fmt.Errorf("rebuild accumulator error: %v", err)
+	}
+	rootHash, err := acc.RootHash()
+	if err != nil {
+		return fmt.Errorf("rebuild accumulator error: %v", err)
+	}
+	if !sha3libra.Equal(rootHash, expectedRootHash) {
+		return errors.New("rebuilt accumulator does not match waypoint")
+	}
+
+	c.accMu.Lock()
+	if c.acc.FrozenSubtreeRoots == nil && c.acc.NumLeaves <= acc.NumLeaves {
+		c.acc = acc
+	}
+	c.accMu.Unlock()
+	return nil
+}
@@ -0,0 +1,87 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/libratest"
+)
+
+func TestBootstrapFromWaypoint(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	s, addrs := libratest.StartServers(t, l)
+
+	var waypoints []string
+	for i := uint64(0); i < 6; i++ {
+		for j := uint64(0); j < i; j++ {
+			libratest.CommitBlock(t, l, i*10+j)
+		}
+		require.NoError(t, l.Reconfigure(nil))
+		waypoints = append(waypoints, l.EpochWaypoint(l.Epoch()-1))
+	}
+	libratest.CommitBlock(t, l, 100)
+
+	ref, err := client.New(addrs[0], l.Waypoint())
+	require.NoError(t, err)
+	defer ref.Close()
+	_, err = ref.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+
+	for i, wp := range waypoints {
+		t.Run(fmt.Sprintf("epoch %d", i+1), func(t *testing.T) {
+			c, err := client.New(addrs[0], wp)
+			require.NoError(t, err)
+			defer c.Close()
+			pli, err := c.QueryLedgerInfo(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, l.Version(), pli.GetVersion())
+			assert.Equal(t, ref.GetState().Subtrees, c.GetState().Subtrees)
+		})
+	}
+
+	t.Run("fork", func(t *testing.T) {
+		c, err := client.New(addrs[0], waypoints[3])
+		require.NoError(t, err)
+		defer c.Close()
+		_, err = c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+
+		// a fork after the known version is detected
+		l2 := l.Fork()
+		libratest.CommitBlock(t, l, 101)
+		libratest.CommitBlock(t, l2, 102)
+		_, err = c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+
+		_, addrs2 := libratest.StartServers(t, l2)
+		c2, err := client.NewFromState(addrs2[0], c.GetState())
+		require.NoError(t, err)
+		defer c2.Close()
+		_, err = c2.QueryLedgerInfo(ctx)
+		assert.Error(t, err)
+	})
+
+	t.Run("tampered proof", func(t *testing.T) {
+		s[0].TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			for _, item := range resp.ResponseItems {
+				if r := item.GetGetTransactionsResponse(); r != nil {
+					siblings := r.TxnListWithProof.Proof.LedgerInfoToTransactionInfosProof.LeftSiblings
+					siblings[0][0] ^= 1
+				}
+			}
+		}
+		defer func() { s[0].TamperResponse = nil }()
+		c, err := client.New(addrs[0], waypoints[4])
+		require.NoError(t, err)
+		defer c.Close()
+		_, err = c.QueryLedgerInfo(ctx)
+		assert.Error(t, err)
+	})
+}
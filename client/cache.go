@@ -0,0 +1,339 @@
+package client
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/the729/go-libra/types"
+)
+
+// Cache is a LRU cache of verified immutable objects: committed transactions by version,
+// and events by event key and sequence number. It is safe for concurrent use, and can be
+// shared by clients of the same ledger.
+//
+// Cached objects are still bound to the proven ledger info which they were verified against,
+// which can be older than the latest ledger info of the client. A transaction list or an
+// event list is served from the cache only if all requested items are cached, and they
+// were verified in the same list, so that the result is proven by a single ledger info.
+// Event lists are served only if they are complete regardless of later events, i.e. the
+// requested number of events all exist.
+type Cache struct {
+	mu        sync.Mutex
+	txns      *lru
+	txnSeqs   map[accountSeq]uint64
+	events    *lru
+	eventKeys *lru
+	stats     CacheStats
+}
+
+// CacheStats is the statistics of a Cache.
+type CacheStats struct {
+	TxnHits     uint64
+	TxnMisses   uint64
+	EventHits   uint64
+	EventMisses uint64
+
+	// Txns and Events are the number of cached transactions and events.
+	Txns   int
+	Events int
+}
+
+func accessPathKey(ap *types.AccessPath) string {
+	return string(ap.Address[:]) + string(ap.Path)
+}
+
+type accountSeq struct {
+	addr types.AccountAddress
+	seq  uint64
+}
+
+type eventSeq struct {
+	key string
+	seq uint64
+}
+
+type cachedTxn struct {
+	txn *types.ProvenTransaction
+	// list is the verified list containing the transaction, if any
+	list   *types.ProvenTransactionList
+	sender *accountSeq
+}
+
+// NewCache creates a Cache, which keeps at most maxTxns transactions and maxEvents events.
+// A non-positive size disables caching of that kind of objects.
+func NewCache(maxTxns, maxEvents int) *Cache {
+	c := &Cache{
+		txnSeqs:   make(map[accountSeq]uint64),
+		events:    newLRU(maxEvents, nil),
+		eventKeys: newLRU(maxEvents, nil),
+	}
+	c.txns = newLRU(maxTxns, func(key, value interface{}) {
+		if s := value.(*cachedTxn).sender; s != nil && c.txnSeqs[*s] == key.(uint64) {
+			delete(c.txnSeqs, *s)
+		}
+	})
+	return c
+}
+
+// Stats returns the statistics of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Txns = c.txns.len()
+	stats.Events = c.events.len()
+	return stats
+}
+
+// Purge removes all cached objects. The statistics are kept.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.txns.purge()
+	c.txnSeqs = make(map[accountSeq]uint64)
+	c.events.purge()
+	c.eventKeys.purge()
+}
+
+func (c *Cache) getTransactionRange(start, limit uint64, withEvents bool) *types.ProvenTransactionList {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ptl *types.ProvenTransactionList
+	if limit > 0 && limit <= uint64(c.txns.max) {
+		var first *cachedTxn
+		for i := uint64(0); i < limit; i++ {
+			value, ok := c.txns.get(start + i)
+			if !ok {
+				first = nil
+				break
+			}
+			if i == 0 {
+				first = value.(*cachedTxn)
+			}
+		}
+		if first != nil && first.list != nil {
+			ptl = first.list.SubList(start, limit)
+		}
+		if ptl != nil && withEvents {
+			for _, txn := range ptl.GetTransactions() {
+				if !txn.GetWithEvents() {
+					ptl = nil
+					break
+				}
+			}
+		}
+	}
+	if ptl == nil {
+		c.stats.TxnMisses++
+		return nil
+	}
+	c.stats.TxnHits++
+	return ptl
+}
+
+func (c *Cache) getTransactionByAccountSeq(addr types.AccountAddress, sequence uint64, withEvents bool) *types.ProvenTransaction {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.txnSeqs[accountSeq{addr, sequence}]; ok {
+		if value, ok := c.txns.get(v); ok {
+			if txn := value.(*cachedTxn).txn; !withEvents || txn.GetWithEvents() {
+				c.stats.TxnHits++
+				return txn
+			}
+		}
+	}
+	c.stats.TxnMisses++
+	return nil
+}
+
+func (c *Cache) addTransactionList(ptl *types.ProvenTransactionList) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, txn := range ptl.GetTransactions() {
+		c.addTransaction(txn, ptl)
+	}
+}
+
+func (c *Cache) addTransactionByAccountSeq(txn *types.ProvenTransaction) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addTransaction(txn, nil)
+}
+
+func (c *Cache) addTransaction(txn *types.ProvenTransaction, ptl *types.ProvenTransactionList) {
+	if c.txns.max <= 0 {
+		return
+	}
+	if value, ok := c.txns.peek(txn.GetVersion()); ok {
+		old := value.(*cachedTxn)
+		if old.txn.GetWithEvents() && !txn.GetWithEvents() {
+			// keep the richer one
+			return
+		}
+		if ptl == nil && old.list != nil && old.txn.GetWithEvents() == txn.GetWithEvents() {
+			return
+		}
+	}
+	entry := &cachedTxn{txn: txn, list: ptl}
+	if stxn := txn.GetSignedTxn(); stxn != nil {
+		entry.sender = &accountSeq{stxn.RawTxn.Sender, stxn.RawTxn.SequenceNumber}
+		c.txnSeqs[*entry.sender] = txn.GetVersion()
+	}
+	c.txns.add(txn.GetVersion(), entry)
+}
+
+func (c *Cache) getEvents(ap *types.AccessPath, start uint64, ascending bool, limit uint64) *types.ProvenEventList {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var pel *types.ProvenEventList
+	key, ok := c.eventKeys.get(accessPathKey(ap))
+	if ok && limit > 0 && limit <= uint64(c.events.max) && (ascending || start >= limit-1) {
+		var first *types.ProvenEventList
+		for i := uint64(0); i < limit; i++ {
+			seq := start + i
+			if !ascending {
+				seq = start - i
+			}
+			value, ok := c.events.get(eventSeq{key.(string), seq})
+			if !ok {
+				first = nil
+				break
+			}
+			if i == 0 {
+				first = value.(*types.ProvenEventList)
+			}
+		}
+		if first != nil {
+			pel = first.SubList(start, ascending, limit)
+		}
+	}
+	if pel == nil {
+		c.stats.EventMisses++
+		return nil
+	}
+	c.stats.EventHits++
+	return pel
+}
+
+func (c *Cache) addEvents(ap *types.AccessPath, pel *types.ProvenEventList) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pevs := pel.GetEvents()
+	// only complete lists can serve later queries
+	if len(pevs) == 0 || !pel.IsComplete() {
+		return
+	}
+	// all events in a verified list have the key of the event handle under the access path
+	key := string(pevs[0].GetEvent().Value.(*types.ContractEventV0).Key)
+	c.eventKeys.add(accessPathKey(ap), key)
+	for _, pev := range pevs {
+		seq := pev.GetEvent().Value.(*types.ContractEventV0).SequenceNumber
+		c.events.add(eventSeq{key, seq}, pel)
+	}
+}
+
+// lru is a least recently used cache. It is not safe for concurrent use.
+type lru struct {
+	max     int
+	ll      *list.List
+	items   map[interface{}]*list.Element
+	onEvict func(key, value interface{})
+}
+
+type lruEntry struct {
+	key, value interface{}
+}
+
+func newLRU(max int, onEvict func(key, value interface{})) *lru {
+	return &lru{
+		max:     max,
+		ll:      list.New(),
+		items:   make(map[interface{}]*list.Element),
+		onEvict: onEvict,
+	}
+}
+
+// get returns the value of the key, and marks it as the most recently used.
+func (l *lru) get(key interface{}) (interface{}, bool) {
+	if e, ok := l.items[key]; ok {
+		l.ll.MoveToFront(e)
+		return e.Value.(*lruEntry).value, true
+	}
+	return nil, false
+}
+
+// peek returns the value of the key, without marking it as used.
+func (l *lru) peek(key interface{}) (interface{}, bool) {
+	if e, ok := l.items[key]; ok {
+		return e.Value.(*lruEntry).value, true
+	}
+	return nil, false
+}
+
+// add adds or replaces the value of the key, and evicts the least recently used entries
+// beyond the size limit.
+func (l *lru) add(key, value interface{}) {
+	if l.max <= 0 {
+		return
+	}
+	if e, ok := l.items[key]; ok {
+		e.Value.(*lruEntry).value = value
+		l.ll.MoveToFront(e)
+		return
+	}
+	l.items[key] = l.ll.PushFront(&lruEntry{key, value})
+	for l.ll.Len() > l.max {
+		e := l.ll.Back()
+		l.ll.Remove(e)
+		ent := e.Value.(*lruEntry)
+		delete(l.items, ent.key)
+		if l.onEvict != nil {
+			l.onEvict(ent.key, ent.value)
+		}
+	}
+}
+
+func (l *lru) len() int {
+	return l.ll.Len()
+}
+
+func (l *lru) purge() {
+	l.ll.Init()
+	l.items = make(map[interface{}]*list.Element)
+}
+
+// SetCache sets the cache of verified immutable objects. A nil cache disables caching,
+// which is the default.
+//
+// With a cache, QueryTransactionRange, QueryTransactionByAccountSeq and
+// QueryEventsByAccessPath, QueryEventListByAccessPath return cached results without contacting the server, if possible.
+// The cache is purged when the state of the client is reset with SetState.
+func (c *Client) SetCache(cache *Cache) {
+	c.epMu.Lock()
+	defer c.epMu.Unlock()
+	c.cache = cache
+}
+
+func (c *Client) getCache() *Cache {
+	c.epMu.Lock()
+	defer c.epMu.Unlock()
+	return c.cache
+}
@@ -0,0 +1,147 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	s, c := libratest.StartServer(t, l)
+	for seq := uint64(0); seq < 3; seq++ {
+		libratest.Transfer(t, c, alice, bob, seq, 10)
+	}
+	requests := 0
+	s.TamperResponse = func(*pbtypes.UpdateToLatestLedgerResponse) { requests++ }
+
+	cache := client.NewCache(100, 100)
+	c.SetCache(cache)
+	n := l.Version() + 1
+	ptl0, err := c.QueryTransactionRange(ctx, 0, n, true)
+	require.NoError(t, err)
+
+	t.Run("transaction range", func(t *testing.T) {
+		requests = 0
+		for _, tc := range []struct {
+			start, limit uint64
+			withEvents   bool
+		}{
+			{0, n, true},
+			{1, 2, true},
+			{n - 1, 1, false},
+		} {
+			ptl, err := c.QueryTransactionRange(ctx, tc.start, tc.limit, tc.withEvents)
+			require.NoError(t, err)
+			assert.Equal(t, ptl0.GetLedgerInfo(), ptl.GetLedgerInfo())
+			txns := ptl.GetTransactions()
+			require.Len(t, txns, int(tc.limit))
+			assert.Equal(t, tc.start, txns[0].GetVersion())
+			assert.Equal(t, ptl0.GetTransactions()[tc.start], txns[0])
+		}
+		assert.Equal(t, 0, requests)
+
+		// more transactions may be committed later
+		_, err = c.QueryTransactionRange(ctx, n-2, 10, false)
+		require.NoError(t, err)
+		_, err = c.QueryTransactionRange(ctx, n, 1, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("transaction by account seq", func(t *testing.T) {
+		requests = 0
+		for seq := uint64(0); seq < 3; seq++ {
+			ptxn, err := c.QueryTransactionByAccountSeq(ctx, alice.Address, seq, true)
+			if !assert.NoError(t, err) {
+				continue
+			}
+			assert.Equal(t, seq, ptxn.GetSignedTxn().RawTxn.SequenceNumber)
+		}
+		// a transaction not committed yet is not cached
+		_, err := c.QueryTransactionByAccountSeq(ctx, alice.Address, 3, false)
+		assert.IsType(t, &client.TransactionNotFoundError{}, err)
+		_, err = c.QueryTransactionByAccountSeq(ctx, alice.Address, 3, false)
+		assert.IsType(t, &client.TransactionNotFoundError{}, err)
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		requests = 0
+		ap := &types.AccessPath{Address: bob.Address, Path: types.AccountReceivedEventPath()}
+		pel0, err := c.QueryEventListByAccessPath(ctx, ap, 0, true, 3)
+		require.NoError(t, err)
+		for _, tc := range []struct {
+			start     uint64
+			ascending bool
+			limit     uint64
+			seqs      []uint64
+		}{
+			{0, true, 3, []uint64{0, 1, 2}},
+			{1, true, 2, []uint64{1, 2}},
+			{2, false, 3, []uint64{2, 1, 0}},
+			{1, false, 1, []uint64{1}},
+		} {
+			pel, err := c.QueryEventListByAccessPath(ctx, ap, tc.start, tc.ascending, tc.limit)
+			require.NoError(t, err)
+			assert.Equal(t, pel0.GetLedgerInfo(), pel.GetLedgerInfo())
+			assert.Equal(t, uint64(3), pel.GetTotalCount())
+			var seqs []uint64
+			for _, pev := range pel.GetEvents() {
+				seqs = append(seqs, pev.GetEvent().Value.(*types.ContractEventV0).SequenceNumber)
+			}
+			assert.Equal(t, tc.seqs, seqs)
+		}
+		assert.Equal(t, 1, requests)
+
+		// more events may be emitted later
+		_, err = c.QueryEventListByAccessPath(ctx, ap, 0, true, 10)
+		require.NoError(t, err)
+		_, err = c.QueryEventListByAccessPath(ctx, ap, ^uint64(0), false, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		stats := cache.Stats()
+		assert.Equal(t, uint64(6), stats.TxnHits)
+		assert.Equal(t, uint64(5), stats.TxnMisses)
+		assert.Equal(t, uint64(4), stats.EventHits)
+		assert.Equal(t, uint64(3), stats.EventMisses)
+		assert.Equal(t, int(n), stats.Txns)
+		assert.Equal(t, 3, stats.Events)
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		cache := client.NewCache(2, 0)
+		c.SetCache(cache)
+		defer c.SetCache(nil)
+		requests = 0
+		_, err := c.QueryTransactionRange(ctx, 1, 3, false)
+		require.NoError(t, err)
+		_, err = c.QueryTransactionRange(ctx, 1, 3, false)
+		require.NoError(t, err)
+		_, err = c.QueryTransactionRange(ctx, 2, 2, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, requests)
+		stats := cache.Stats()
+		assert.Equal(t, client.CacheStats{TxnHits: 1, TxnMisses: 2, Txns: 2}, stats)
+	})
+
+	t.Run("purged by set state", func(t *testing.T) {
+		c.SetCache(cache)
+		require.NoError(t, c.SetState(c.GetState()))
+		assert.Equal(t, 0, cache.Stats().Txns)
+		assert.Equal(t, 0, cache.Stats().Events)
+	})
+}
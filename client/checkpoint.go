@@ -0,0 +1,105 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/the729/go-libra/internal/fileutil"
+)
+
+// CheckpointStore persists the cursors of event streams. Each cursor is the sequence
+// number of the next event to be processed, identified by a key.
+type CheckpointStore interface {
+	// LoadCheckpoint loads the cursor with the key. If the cursor is not found,
+	// ok is false.
+	LoadCheckpoint(key string) (seq uint64, ok bool, err error)
+
+	// SaveCheckpoint saves the cursor with the key.
+	SaveCheckpoint(key string, seq uint64) error
+}
+
+// MemoryCheckpointStore is a CheckpointStore in memory.
+type MemoryCheckpointStore struct {
+	mu      sync.Mutex
+	cursors map[string]uint64
+}
+
+// NewMemoryCheckpointStore creates an empty MemoryCheckpointStore.
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{cursors: make(map[string]uint64)}
+}
+
+// LoadCheckpoint implements CheckpointStore.
+func (s *MemoryCheckpointStore) LoadCheckpoint(key string) (uint64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seq, ok := s.cursors[key]
+	return seq, ok, nil
+}
+
+// SaveCheckpoint implements CheckpointStore.
+func (s *MemoryCheckpointStore) SaveCheckpoint(key string, seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[key] = seq
+	return nil
+}
+
+// FileCheckpointStore is a CheckpointStore backed by a JSON file. The file is replaced
+// atomically on each save, so that a crash never leaves a partially written file.
+type FileCheckpointStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileCheckpointStore creates a FileCheckpointStore with the file path. The file is
+// created on the first save if it does not exist.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+func (s *FileCheckpointStore) load() (map[string]uint64, error) {
+	cursors := make(map[string]uint64)
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return cursors, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cursors); err != nil {
+		return nil, fmt.Errorf("checkpoint file %s corrupted: %v", s.path, err)
+	}
+	return cursors, nil
+}
+
+// LoadCheckpoint implements CheckpointStore.
+func (s *FileCheckpointStore) LoadCheckpoint(key string) (uint64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cursors, err := s.load()
+	if err != nil {
+		return 0, false, err
+	}
+	seq, ok := cursors[key]
+	return seq, ok, nil
+}
+
+// SaveCheckpoint implements CheckpointStore.
+func (s *FileCheckpointStore) SaveCheckpoint(key string, seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cursors, err := s.load()
+	if err != nil {
+		return err
+	}
+	cursors[key] = seq
+	data, err := json.MarshalIndent(cursors, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(s.path, data)
+}
@@ -6,7 +6,16 @@ Features include:
   - Query account state
   - Query transactions by range
   - Query single transaction by account and sequence number
-  - Sign and submit raw transactions
+  - Sign and submit raw transactions, and wait for their execution results
+  - Manage sequence numbers locally for concurrent senders
+  - Batch queries, which are verified against the same ledger info
+  - Fail over among multiple servers, and cross check their ledgers
+  - Subscribe to newly verified ledger infos
+  - Follow events of an access path, with resumable checkpoints
+  - Iterate over transactions of any version range, or following the ledger tip
+  - Persist the trusted state automatically to a pluggable state store
+  - Catch up with many epochs page by page, with progress reporting
+  - Cache verified transactions and events in memory
 
 All queries are cryptographically verified to proof their inclusion and integrity in the blockchain.
 
@@ -19,15 +28,16 @@ version and the Merkle tree accumulator.
 
 You should extract the known-version state of a client instance before destroying it, by calling GetKnownVersion(),
 and saving the result somewhere. Later, when a new client instance is constructed, you should use SetKnownVersion()
-to restore the known-version state.
+to restore the known-version state. Alternatively, set a StateStore, to which the client saves its state
+whenever it advances.
 */
 package client
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 
-	"github.com/the729/go-libra/generated/pbac"
 	"github.com/the729/go-libra/types"
 	"github.com/the729/go-libra/types/proof/accumulator"
 )
@@ -35,12 +45,24 @@ import (
 // Client is a Libra client.
 // It has a gRPC client to a Libra RPC server, with public keys to trusted peers.
 type Client struct {
-	closeFunc    func()
-	ac           pbac.AdmissionControlClient
+	endpoints    []*endpoint
+	epMu         sync.Mutex
+	current      int
+	crossCheck   bool
+	retryPolicy  *RetryPolicy
+	pollPolicy   *PollPolicy
+	catchUpFunc  EpochCatchUpFunc
+	cache        *Cache
 	verifier     types.LedgerInfoVerifier
 	acc          *accumulator.Accumulator
 	accMu        sync.RWMutex
 	lastWaypoint string
+
+	lastEpochChange *types.ProvenLedgerInfo
+
+	stateStore     StateStore
+	lastSavedState *State
+	storeMu        sync.Mutex
 }
 
 // New creates a new Libra Client from a trusted waypoint.
@@ -48,8 +70,10 @@ type Client struct {
 // For usage in golang, ServerAddr is in host:port format. For use with Javascript,
 // ServerAddr is in http://host:port format.
 //
-// Waypoint is a trusted waypoint in the format of "version:hash". Currently, version
-// has to be 0 in order to make consistency check work.
+// Waypoint is a trusted waypoint in the format of "version:hash". It is either the genesis
+// waypoint, or the waypoint of any epoch change. With a non-genesis waypoint, the client
+// rebuilds the accumulator at the waypoint version with its first query, without
+// replaying the history.
 //
 // Waypoint can also be "insecure", meaning that the client will trust whatever the ledger
 // has. This is useful with the testnet, which gets reset every now and then. Do not rely
@@ -66,20 +90,46 @@ func New(ServerAddr, Waypoint string) (*Client, error) {
 // The state includes validator set and known version subtrees. It can be exported by
 // calling GetState().
 func NewFromState(ServerAddr string, state *State) (*Client, error) {
+	return NewMultiEndpointFromState([]string{ServerAddr}, state)
+}
+
+// NewMultiEndpoint creates a new Libra Client connected to multiple servers, from a
+// trusted waypoint.
+//
+// Requests are sent to one server at a time. On transport errors, the client fails over to
+// the next server. All servers share one trusted state, so the ledger consistency is kept
+// across servers.
+func NewMultiEndpoint(ServerAddrs []string, Waypoint string) (*Client, error) {
+	return NewMultiEndpointFromState(ServerAddrs, &State{Waypoint: Waypoint})
+}
+
+// NewMultiEndpointFromState creates a new Libra Client connected to multiple servers, from
+// a previous saved state.
+func NewMultiEndpointFromState(ServerAddrs []string, state *State) (*Client, error) {
+	if len(ServerAddrs) == 0 {
+		return nil, errors.New("no server address")
+	}
 	c := &Client{}
 	if err := c.SetState(state); err != nil {
 		return nil, fmt.Errorf("invalid state: %v", err)
 	}
-	if err := c.connect(ServerAddr); err != nil {
-		return nil, err
+	for _, addr := range ServerAddrs {
+		ep, err := dial(addr)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.endpoints = append(c.endpoints, ep)
 	}
 	return c, nil
 }
 
 // Close the client.
 func (c *Client) Close() {
-	if c.closeFunc != nil {
-		c.closeFunc()
+	for _, ep := range c.endpoints {
+		if ep.closeFunc != nil {
+			ep.closeFunc()
+		}
 	}
 }
 
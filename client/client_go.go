@@ -6,17 +6,29 @@ import (
 	"fmt"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/the729/go-libra/generated/pbac"
 )
 
-func (c *Client) connect(server string) error {
+func dial(server string, opts ...grpc.DialOption) (*endpoint, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithInsecure()}
+	}
 	// Set up a connection to the server.
-	conn, err := grpc.Dial(server, grpc.WithInsecure())
+	conn, err := grpc.Dial(server, opts...)
 	if err != nil {
-		return fmt.Errorf("grpc dial error: %v", err)
+		return nil, fmt.Errorf("grpc dial error: %v", err)
 	}
-	c.closeFunc = func() { conn.Close() }
-	c.ac = pbac.NewAdmissionControlClient(conn)
-	return nil
+	return &endpoint{
+		addr:      server,
+		ac:        pbac.NewAdmissionControlClient(conn),
+		closeFunc: func() { conn.Close() },
+	}, nil
+}
+
+// errorCode returns the gRPC status code of an error returned by a RPC call.
+func errorCode(err error) codes.Code {
+	return status.Code(err)
 }
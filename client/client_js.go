@@ -3,10 +3,23 @@
 package client
 
 import (
+	"github.com/johanbrandhorst/protobuf/grpcweb/status"
+	"google.golang.org/grpc/codes"
+
 	"github.com/the729/go-libra/generated/pbac"
 )
 
-func (c *Client) connect(server string) error {
-	c.ac = pbac.NewAdmissionControlClient(server)
-	return nil
+func dial(server string) (*endpoint, error) {
+	return &endpoint{
+		addr: server,
+		ac:   pbac.NewAdmissionControlClient(server),
+	}, nil
+}
+
+// errorCode returns the gRPC status code of an error returned by a RPC call.
+func errorCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	return status.FromError(err).Code
 }
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/the729/go-libra/crypto/sha3libra"
+	"github.com/the729/go-libra/generated/pbac"
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/types"
+)
+
+type endpoint struct {
+	addr      string
+	ac        pbac.AdmissionControlClient
+	closeFunc func()
+}
+
+// LedgerDisagreementError is returned when two servers present validly signed ledger infos
+// with different transaction accumulator hashes at the same version.
+type LedgerDisagreementError struct {
+	Version   uint64
+	Endpoints [2]string
+}
+
+func (e *LedgerDisagreementError) Error() string {
+	return fmt.Sprintf("ledger disagreement at version %d between %s and %s", e.Version, e.Endpoints[0], e.Endpoints[1])
+}
+
+// EndpointError is returned by a cross checked request, when a server is reachable, but
+// responds with an error, or its response fails the verification.
+type EndpointError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *EndpointError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Endpoint, e.Err)
+}
+
+// SetCrossCheck enables or disables cross checking among servers.
+//
+// When enabled, every UpdateToLatestLedger request is sent to all servers. All responses are
+// verified, and a *LedgerDisagreementError is returned if any two servers disagree on the
+// transaction accumulator hash at the same version. Unreachable servers are ignored, as long
+// as at least one server responds. Any other failure of a server, including a response which
+// fails the verification, fails the request with an *EndpointError.
+func (c *Client) SetCrossCheck(enabled bool) {
+	c.epMu.Lock()
+	defer c.epMu.Unlock()
+	c.crossCheck = enabled
+}
+
+// CurrentEndpoint returns the address of the server which requests are currently sent to.
+func (c *Client) CurrentEndpoint() string {
+	c.epMu.Lock()
+	defer c.epMu.Unlock()
+	return c.endpoints[c.current].addr
+}
+
+// isTransportError returns true if the error means that the server is not reachable.
+func isTransportError(err error) bool {
+	return errorCode(err) == codes.Unavailable
+}
+
+// withFailover calls f with endpoints in turn, starting from the current one, until f
+// succeeds or returns an error other than a transport error.
+func (c *Client) withFailover(ctx context.Context, f func(ep *endpoint) error) error {
+	c.epMu.Lock()
+	start := c.current
+	c.epMu.Unlock()
+
+	var err error
+	for i := range c.endpoints {
+		idx := (start + i) % len(c.endpoints)
+		err = f(c.endpoints[idx])
+		if err == nil || !isTransportError(err) || ctx.Err() != nil {
+			return err
+		}
+		c.epMu.Lock()
+		if c.current == idx {
+			c.current = (idx + 1) % len(c.endpoints)
+		}
+		c.epMu.Unlock()
+	}
+	return err
+}
+
+// fanOut sends the request to all endpoints, and verifies all responses. It returns the
+// response with the highest ledger version.
+//
+// Note that a reachable server which returns an error, or fails the verification, fails the
+// whole request, because it is either malicious, broken, or on a different ledger. Only
+// transport errors are ignored.
+func (c *Client) fanOut(
+	ctx context.Context, req *pbtypes.UpdateToLatestLedgerRequest,
+	numLeaves uint64, frozenSubtreeRoots [][]byte,
+) (*pbtypes.UpdateToLatestLedgerResponse, *types.ProvenLedgerInfo, error) {
+	type result struct {
+		resp *pbtypes.UpdateToLatestLedgerResponse
+		err  error
+	}
+	results := make([]result, len(c.endpoints))
+	done := make(chan struct{})
+	for idx, ep := range c.endpoints {
+		go func(idx int, ep *endpoint) {
+			resp, err := ep.ac.UpdateToLatestLedger(ctx, req)
+			results[idx] = result{resp, err}
+			done <- struct{}{}
+		}(idx, ep)
+	}
+	for range c.endpoints {
+		<-done
+	}
+
+	var bestResp *pbtypes.UpdateToLatestLedgerResponse
+	var bestPli *types.ProvenLedgerInfo
+	var lastErr error
+	var updates []*ledgerUpdate
+	verified := make(map[uint64]int)
+	plis := make([]*types.ProvenLedgerInfo, len(c.endpoints))
+	for idx, r := range results {
+		if r.err != nil {
+			if !isTransportError(r.err) {
+				return nil, nil, &EndpointError{Endpoint: c.endpoints[idx].addr, Err: r.err}
+			}
+			lastErr = r.err
+			continue
+		}
+		pli, update, err := c.verifyLedgerInfo(r.resp, numLeaves, frozenSubtreeRoots)
+		if err == errMoreEpochChanges {
+			return nil, nil, err
+		}
+		if err != nil {
+			return nil, nil, &EndpointError{Endpoint: c.endpoints[idx].addr, Err: err}
+		}
+		if other, ok := verified[pli.GetVersion()]; ok {
+			if !sha3libra.Equal(pli.GetTransactionAccumulatorHash(), plis[other].GetTransactionAccumulatorHash()) {
+				return nil, nil, &LedgerDisagreementError{
+					Version:   pli.GetVersion(),
+					Endpoints: [2]string{c.endpoints[other].addr, c.endpoints[idx].addr},
+				}
+			}
+		} else {
+			verified[pli.GetVersion()] = idx
+		}
+		plis[idx] = pli
+		updates = append(updates, update)
+		if bestPli == nil || pli.GetVersion() > bestPli.GetVersion() {
+			bestResp, bestPli = r.resp, pli
+		}
+	}
+	if bestResp == nil {
+		return nil, nil, lastErr
+	}
+
+	// the trusted state is updated only if all servers agree
+	advanced := false
+	for _, u := range updates {
+		if c.applyLedgerUpdate(u) {
+			advanced = true
+		}
+	}
+	if advanced {
+		if err := c.saveState(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return bestResp, bestPli, nil
+}
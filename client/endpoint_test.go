@@ -0,0 +1,106 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/libratest"
+)
+
+func TestFailover(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	servers, addrs := libratest.StartServers(t, l, l)
+	c, err := client.NewMultiEndpoint(addrs, l.Waypoint())
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, addrs[0], c.CurrentEndpoint())
+
+	servers[0].Stop()
+	libratest.CommitBlock(t, l, 100)
+	pli, err := c.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, l.Version(), pli.GetVersion())
+	assert.Equal(t, addrs[1], c.CurrentEndpoint())
+
+	servers[1].Stop()
+	_, err = c.QueryLedgerInfo(ctx)
+	assert.Error(t, err)
+}
+
+func TestCrossCheck(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	l2 := l.Fork()
+	servers, addrs := libratest.StartServers(t, l, l2, l)
+	c, err := client.NewMultiEndpoint(addrs, l.Waypoint())
+	require.NoError(t, err)
+	defer c.Close()
+	c.SetCrossCheck(true)
+
+	_, err = c.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+	knownVersion := c.GetState().KnownVersion
+
+	t.Run("disagreement", func(t *testing.T) {
+		libratest.CommitBlock(t, l, 100)
+		libratest.CommitBlock(t, l2, 101)
+		_, err := c.QueryLedgerInfo(ctx)
+		require.Error(t, err)
+		derr, ok := err.(*client.LedgerDisagreementError)
+		require.True(t, ok, err.Error())
+		assert.Equal(t, l.Version(), derr.Version)
+		assert.Equal(t, knownVersion, c.GetState().KnownVersion)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		servers[1].Stop()
+		pli, err := c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, l.Version(), pli.GetVersion())
+	})
+
+	t.Run("verification failure", func(t *testing.T) {
+		servers[2].TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			b := resp.LedgerInfoWithSigs.Bytes
+			b[len(b)-1] ^= 1
+		}
+		defer func() { servers[2].TamperResponse = nil }()
+		_, err := c.QueryLedgerInfo(ctx)
+		require.Error(t, err)
+		eerr, ok := err.(*client.EndpointError)
+		require.True(t, ok, err.Error())
+		assert.Equal(t, addrs[2], eerr.Endpoint)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		failing := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			if cc.Target() == addrs[2] {
+				return status.Error(codes.Internal, "injected failure")
+			}
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		c, err := client.NewWithOptions(addrs[0], l.Waypoint(),
+			client.WithEndpoints(addrs[1:]...), client.WithCrossCheck(), client.WithUnaryInterceptors(failing))
+		require.NoError(t, err)
+		defer c.Close()
+
+		_, err = c.QueryLedgerInfo(ctx)
+		require.Error(t, err)
+		eerr, ok := err.(*client.EndpointError)
+		require.True(t, ok, err.Error())
+		assert.Equal(t, addrs[2], eerr.Endpoint)
+		assert.Equal(t, codes.Internal, status.Code(eerr.Err))
+	})
+}
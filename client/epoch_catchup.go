@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/types"
+)
+
+// EpochCatchUpFunc is called after each page of epoch changes is verified, when the client
+// catches up with many epochs. The epochChange is the last verified epoch change ledger info,
+// so the client trusts the validator set of epoch epochChange.GetEpochNum()+1. The catch up
+// is done when it reaches targetEpoch, which is the epoch of the latest ledger info.
+type EpochCatchUpFunc func(epochChange *types.ProvenLedgerInfo, targetEpoch uint64)
+
+// SetEpochCatchUpFunc sets a function to report the progress of catching up with epochs.
+// A nil function disables reporting.
+//
+// A server sends a limited number of epoch changes in one response. If the client is behind
+// by more epochs, it requests the remaining epoch changes page by page, and the trusted state
+// is updated and saved to the state store after each page.
+func (c *Client) SetEpochCatchUpFunc(f EpochCatchUpFunc) {
+	c.epMu.Lock()
+	defer c.epMu.Unlock()
+	c.catchUpFunc = f
+}
+
+// catchUpEpochs requests and verifies pages of epoch changes, until the client trusts the
+// validator set of the latest epoch.
+//
+// The requests start from the version of the last verified epoch change, instead of the
+// known version of the accumulator, so that the server sends the following epoch changes.
+// The ledger infos and consistency proofs in these responses are not used.
+func (c *Client) catchUpEpochs(ctx context.Context) error {
+	c.epMu.Lock()
+	report := c.catchUpFunc
+	c.epMu.Unlock()
+
+	for {
+		c.accMu.RLock()
+		verifier := c.verifier
+		knownVersion := c.acc.NumLeaves - 1
+		lastWaypoint := c.lastWaypoint
+		c.accMu.RUnlock()
+
+		wp := &types.Waypoint{}
+		if err := wp.UnmarshalText([]byte(lastWaypoint)); err == nil && wp.Version > knownVersion {
+			knownVersion = wp.Version
+		}
+		req := &pbtypes.UpdateToLatestLedgerRequest{ClientKnownVersion: knownVersion}
+		var resp *pbtypes.UpdateToLatestLedgerResponse
+		err := c.withRetry(ctx, false, func(int) error {
+			return c.withFailover(ctx, func(ep *endpoint) error {
+				var err error
+				resp, err = ep.ac.UpdateToLatestLedger(ctx, req)
+				return err
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		li := &types.LedgerInfoWithSignatures{}
+		if err := li.FromProto(resp.LedgerInfoWithSigs); err != nil {
+			return fmt.Errorf("unmarshal ledgerInfoWithSigs error: %v", err)
+		}
+		targetEpoch := li.Value.(*types.LedgerInfoWithSignaturesV0).Epoch
+		if !verifier.EpochChangeVerificationRequired(targetEpoch) {
+			return nil
+		}
+
+		u, _, err := verifyValidatorChange(verifier, resp.ValidatorChangeProof)
+		if err == errMoreEpochChanges {
+			return fmt.Errorf("no progress in epoch catch up from version %d", knownVersion)
+		}
+		if err != nil {
+			return err
+		}
+		if c.applyLedgerUpdate(u) {
+			if err := c.saveState(); err != nil {
+				return err
+			}
+		}
+		if report != nil {
+			report(u.epochChange, targetEpoch)
+		}
+	}
+}
+
+// queryEpochChanges requests and verifies the epoch change ledger infos following a verified
+// one, page by page, up to the epoch change of toEpoch. The trusted state of the client is
+// not updated.
+func (c *Client) queryEpochChanges(ctx context.Context, from *types.ProvenLedgerInfo, toEpoch uint64) ([]*types.ProvenLedgerInfo, error) {
+	var plis []*types.ProvenLedgerInfo
+	for from.GetEpochNum() < toEpoch {
+		verifier, err := from.ToVerifier()
+		if err != nil {
+			return nil, err
+		}
+		req := &pbtypes.UpdateToLatestLedgerRequest{ClientKnownVersion: from.GetVersion()}
+		var resp *pbtypes.UpdateToLatestLedgerResponse
+		err = c.withRetry(ctx, false, func(int) error {
+			return c.withFailover(ctx, func(ep *endpoint) error {
+				var err error
+				resp, err = ep.ac.UpdateToLatestLedger(ctx, req)
+				return err
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		vcp := &types.ValidatorChangeProof{}
+		if err := vcp.FromProto(resp.ValidatorChangeProof); err != nil {
+			return nil, fmt.Errorf("validator change proof invalid: %v", err)
+		}
+		pvc, err := vcp.Verify(verifier)
+		if err == types.ErrNoNewValidatorChange {
+			return nil, fmt.Errorf("no epoch change after epoch %d, expecting up to epoch %d", from.GetEpochNum(), toEpoch)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("validator change proof verification error: %v", err)
+		}
+		for _, pli := range pvc.GetLedgerInfos() {
+			if pli.GetEpochNum() > toEpoch {
+				break
+			}
+			plis = append(plis, pli)
+		}
+		from = pvc.GetLastLedgerInfo()
+	}
+	return plis, nil
+}
@@ -0,0 +1,143 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestEpochCatchUp(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	servers, addrs := libratest.StartServers(t, l, l)
+	for _, s := range servers {
+		s.MaxEpochChanges = 2
+	}
+
+	c0, err := client.New(addrs[0], l.Waypoint())
+	require.NoError(t, err)
+	defer c0.Close()
+	_, err = c0.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+	state0 := c0.GetState()
+
+	// a ledger info of the epoch of state0
+	var staleLedgerInfo *pbtypes.LedgerInfoWithSignatures
+	servers[0].TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+		staleLedgerInfo = resp.LedgerInfoWithSigs
+	}
+	_, err = c0.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+	servers[0].TamperResponse = nil
+
+	for i := 0; i < 6; i++ {
+		require.NoError(t, l.Reconfigure(libratest.GenerateValidators(4)))
+		libratest.CommitBlock(t, l, uint64(i))
+	}
+
+	// catchUp queries the latest ledger info, and checks the progress and saved states.
+	catchUp := func(t *testing.T, c *client.Client) []*client.State {
+		store := client.NewMemoryStateStore(100)
+		c.SetStateStore(store)
+		var epochs []uint64
+		c.SetEpochCatchUpFunc(func(epochChange *types.ProvenLedgerInfo, targetEpoch uint64) {
+			assert.Equal(t, l.Epoch(), targetEpoch)
+			epochs = append(epochs, epochChange.GetEpochNum()+1)
+		})
+
+		pli, err := c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, l.Epoch(), pli.GetEpochNum())
+		assert.Equal(t, l.Version(), pli.GetVersion())
+
+		require.True(t, len(epochs) > 1)
+		for i := 1; i < len(epochs); i++ {
+			assert.True(t, epochs[i] > epochs[i-1])
+		}
+		assert.Equal(t, l.Epoch(), epochs[len(epochs)-1])
+
+		// each page is saved, then the latest version
+		states, err := store.LoadStates()
+		require.NoError(t, err)
+		require.Len(t, states, len(epochs)+1)
+		for i, epoch := range epochs {
+			assert.Equal(t, epoch, states[len(epochs)-i].Epoch)
+		}
+		assert.Equal(t, l.Version(), states[0].KnownVersion)
+		assert.Equal(t, l.Epoch(), states[0].Epoch)
+		return states
+	}
+
+	t.Run("single endpoint", func(t *testing.T) {
+		c, err := client.NewFromState(addrs[0], state0)
+		require.NoError(t, err)
+		defer c.Close()
+		states := catchUp(t, c)
+
+		// resume from an interrupted catch up, whose epoch is ahead of the known version
+		intermediate := states[len(states)-1]
+		assert.True(t, intermediate.Epoch > state0.Epoch)
+		assert.Equal(t, state0.KnownVersion, intermediate.KnownVersion)
+		c, err = client.NewFromState(addrs[0], intermediate)
+		require.NoError(t, err)
+		defer c.Close()
+		catchUp(t, c)
+	})
+
+	t.Run("cross check", func(t *testing.T) {
+		c, err := client.NewMultiEndpointFromState(addrs, state0)
+		require.NoError(t, err)
+		defer c.Close()
+		c.SetCrossCheck(true)
+		catchUp(t, c)
+	})
+
+	t.Run("from genesis", func(t *testing.T) {
+		c, err := client.New(addrs[0], l.Waypoint())
+		require.NoError(t, err)
+		defer c.Close()
+		catchUp(t, c)
+	})
+
+	t.Run("tampered page", func(t *testing.T) {
+		c, err := client.NewFromState(addrs[0], state0)
+		require.NoError(t, err)
+		defer c.Close()
+		servers[0].TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			lis := resp.ValidatorChangeProof.LedgerInfoWithSigs
+			b := lis[len(lis)-1].Bytes
+			b[len(b)-1] ^= 1
+		}
+		defer func() { servers[0].TamperResponse = nil }()
+		_, err = c.QueryLedgerInfo(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, state0.Epoch, c.GetState().Epoch)
+	})
+
+	t.Run("no progress", func(t *testing.T) {
+		c, err := client.NewFromState(addrs[0], state0)
+		require.NoError(t, err)
+		defer c.Close()
+		// more epoch changes are reported for queries, while catch up requests are told
+		// that the trusted epoch is the latest
+		servers[0].TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			if len(resp.ResponseItems) == 0 {
+				resp.LedgerInfoWithSigs = staleLedgerInfo
+			}
+		}
+		defer func() { servers[0].TamperResponse = nil }()
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		_, err = c.QueryAccountState(ctx, types.AccountAddress{})
+		assert.Error(t, err)
+		assert.NoError(t, ctx.Err())
+	})
+}
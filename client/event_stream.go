@@ -0,0 +1,140 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/the729/go-libra/types"
+)
+
+// Default settings of EventStream.
+const (
+	DefaultEventPageSize     = 100
+	DefaultEventPollInterval = time.Second
+)
+
+// EventStream follows the events under an access path, e.g. the received events of an
+// account, starting from a cursor persisted in a CheckpointStore.
+//
+// Events are queried page by page in ascending order, verified, and passed to a handler
+// one at a time. After the handler returns successfully, the cursor is moved past the
+// event and saved. So after a restart, the stream resumes from the first event which has
+// not been checkpointed.
+//
+// Delivery is at-least-once, not exactly-once: if the process stops, or saving the
+// checkpoint fails, after the handler returns but before the checkpoint is saved, the
+// event is handled again. Handlers must be idempotent, e.g. by recording the sequence
+// number of the last handled event along with their own side effects, and skipping
+// events up to it.
+type EventStream struct {
+	// PageSize is the max number of events per query.
+	PageSize uint64
+
+	// PollInterval is the wait time before querying again, after all existing events
+	// have been handled.
+	PollInterval time.Duration
+
+	c     *Client
+	ap    *types.AccessPath
+	key   string
+	store CheckpointStore
+	next  uint64
+	evKey types.EventKey
+}
+
+// NewEventStream creates an EventStream on the access path. The cursor is loaded from
+// the checkpoint store. If there is no checkpoint, the stream starts from the first event.
+func (c *Client) NewEventStream(ap *types.AccessPath, store CheckpointStore) (*EventStream, error) {
+	s := &EventStream{
+		PageSize:     DefaultEventPageSize,
+		PollInterval: DefaultEventPollInterval,
+		c:            c,
+		ap:           ap.Clone(),
+		key:          EventStreamKey(ap),
+		store:        store,
+	}
+	next, _, err := store.LoadCheckpoint(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint error: %v", err)
+	}
+	s.next = next
+	return s, nil
+}
+
+// EventStreamKey returns the key of the access path in a CheckpointStore, which is
+// "<hex address>/<hex path>".
+func EventStreamKey(ap *types.AccessPath) string {
+	return hex.EncodeToString(ap.Address[:]) + "/" + hex.EncodeToString(ap.Path)
+}
+
+// Cursor returns the sequence number of the next event to be handled.
+func (s *EventStream) Cursor() uint64 {
+	return s.next
+}
+
+// Run handles events until the context is done, or an error occurs. If the handler
+// returns an error, the event is not checkpointed, and Run returns the error. An event
+// may be passed to the handler more than once across runs. See EventStream.
+//
+// Run returns nil when the context is done. It must not be called concurrently.
+func (s *EventStream) Run(ctx context.Context, handler func(*types.ProvenEvent) error) error {
+	if s.PageSize == 0 {
+		return errors.New("zero page size")
+	}
+	for {
+		pel, err := s.c.QueryEventListByAccessPath(ctx, s.ap, s.next, true, s.PageSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		pevs := pel.GetEvents()
+		for _, pev := range pevs {
+			if err := s.checkEvent(pev); err != nil {
+				return err
+			}
+			if err := handler(pev); err != nil {
+				return err
+			}
+			if err := s.store.SaveCheckpoint(s.key, s.next+1); err != nil {
+				return fmt.Errorf("save checkpoint error: %v", err)
+			}
+			s.next++
+		}
+		if pel.IsComplete() && s.next < pel.GetTotalCount() {
+			continue
+		}
+		if !pel.IsComplete() && uint64(len(pevs)) == s.PageSize {
+			continue
+		}
+		timer := time.NewTimer(s.PollInterval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		}
+	}
+}
+
+// checkEvent makes sure that events are of the same handle, and are in order without gaps.
+func (s *EventStream) checkEvent(pev *types.ProvenEvent) error {
+	ev, ok := pev.GetEvent().Value.(*types.ContractEventV0)
+	if !ok {
+		return errors.New("unknown event version")
+	}
+	if ev.SequenceNumber != s.next {
+		return fmt.Errorf("unexpected event sequence number: expected %d, got %d", s.next, ev.SequenceNumber)
+	}
+	if s.evKey == nil {
+		s.evKey = ev.Key
+	} else if !bytes.Equal(s.evKey, ev.Key) {
+		return fmt.Errorf("event key changed: %x, %x", s.evKey, ev.Key)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestEventStream(t *testing.T) {
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	_, c := libratest.StartServer(t, l)
+	for seq := uint64(0); seq < 3; seq++ {
+		libratest.Transfer(t, c, alice, bob, seq, 10)
+	}
+
+	store := client.NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoints.json"))
+	ap := &types.AccessPath{Address: bob.Address, Path: types.AccountReceivedEventPath()}
+	newStream := func() *client.EventStream {
+		s, err := c.NewEventStream(ap, store)
+		require.NoError(t, err)
+		s.PageSize = 2
+		s.PollInterval = 10 * time.Millisecond
+		return s
+	}
+
+	// collect runs the stream until n events are handled
+	collect := func(s *client.EventStream, n int) []uint64 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var seqs []uint64
+		err := s.Run(ctx, func(pev *types.ProvenEvent) error {
+			seqs = append(seqs, pev.GetEvent().Value.(*types.ContractEventV0).SequenceNumber)
+			if len(seqs) == n {
+				cancel()
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		return seqs
+	}
+
+	s := newStream()
+	assert.Equal(t, uint64(0), s.Cursor())
+	assert.Equal(t, []uint64{0, 1, 2}, collect(s, 3))
+	assert.Equal(t, uint64(3), s.Cursor())
+
+	t.Run("handler error", func(t *testing.T) {
+		libratest.Transfer(t, c, alice, bob, 3, 10)
+		libratest.Transfer(t, c, alice, bob, 4, 10)
+
+		s := newStream()
+		assert.Equal(t, uint64(3), s.Cursor())
+		errHandler := errors.New("handler error")
+		err := s.Run(context.Background(), func(*types.ProvenEvent) error { return errHandler })
+		assert.Equal(t, errHandler, err)
+		assert.Equal(t, uint64(3), s.Cursor())
+
+		// resumes from the checkpoint
+		assert.Equal(t, []uint64{3, 4}, collect(newStream(), 2))
+		seq, ok, err := store.LoadCheckpoint(client.EventStreamKey(ap))
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(5), seq)
+	})
+}
@@ -0,0 +1,94 @@
+package client_test
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestMultiSig(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	bob := libratest.NewAccount(t, l, 0)
+	_, c := libratest.StartServer(t, l)
+
+	var pubkeys []ed25519.PublicKey
+	var prikeys []ed25519.PrivateKey
+	for i := 0; i < 3; i++ {
+		pubkey, prikey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		pubkeys = append(pubkeys, pubkey)
+		prikeys = append(prikeys, prikey)
+	}
+	multiKey, err := types.NewMultiEd25519PublicKey(pubkeys, 2)
+	require.NoError(t, err)
+	addr := multiKey.Address()
+	authKey := multiKey.AuthKey()
+	require.NoError(t, l.CreateAccount(addr, authKey[:], 1000))
+
+	parsed, err := types.ParseMultiEd25519PublicKey(multiKey.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, multiKey, parsed)
+
+	rawTxn, err := client.NewRawP2PTransaction(addr, bob.Address, nil, 0, 10, 10000, 0, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	collector, err := types.NewMultiSigCollector(rawTxn, multiKey)
+	require.NoError(t, err)
+
+	t.Run("below threshold", func(t *testing.T) {
+		sig, err := rawTxn.SignPartial(multiKey, prikeys[2])
+		require.NoError(t, err)
+		assert.Equal(t, uint8(2), sig.Index)
+		require.NoError(t, collector.Add(sig))
+		assert.False(t, collector.Ready())
+		_, err = collector.SignedTransaction()
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid partial signature", func(t *testing.T) {
+		sig, err := rawTxn.SignPartial(multiKey, prikeys[1])
+		require.NoError(t, err)
+		sig.Index = 0
+		assert.Error(t, collector.Add(sig))
+
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		_, err = rawTxn.SignPartial(multiKey, otherKey)
+		assert.Error(t, err)
+	})
+
+	t.Run("submit", func(t *testing.T) {
+		sig, err := rawTxn.SignPartial(multiKey, prikeys[0])
+		require.NoError(t, err)
+		require.NoError(t, collector.Add(sig))
+		assert.True(t, collector.Ready())
+		stxn, err := collector.SignedTransaction()
+		require.NoError(t, err)
+		require.NoError(t, stxn.VerifySignature())
+
+		_, err = c.SubmitSignedTransaction(ctx, stxn)
+		require.NoError(t, err)
+		ptxn, err := c.QueryTransactionByAccountSeq(ctx, addr, 0, false)
+		require.NoError(t, err)
+		assert.Equal(t, types.EXECUTED, ptxn.GetMajorStatus())
+		assert.Equal(t, stxn.Authenticator, ptxn.GetSignedTxn().Authenticator)
+	})
+
+	t.Run("tampered signature", func(t *testing.T) {
+		stxn, err := collector.SignedTransaction()
+		require.NoError(t, err)
+		a := stxn.Authenticator.(*types.MultiEd25519Authenticator)
+		// clear the bit of key 0
+		a.Signature[len(a.Signature)-4] &^= 0x80
+		assert.Error(t, stxn.VerifySignature())
+	})
+}
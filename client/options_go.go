@@ -0,0 +1,207 @@
+// +build !js
+
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/the729/go-libra/generated/pbac"
+)
+
+// Option configures a Client created by NewWithOptions.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	dialOpts   []grpc.DialOption
+	secure     bool
+	conn       *grpc.ClientConn
+	ac         pbac.AdmissionControlClient
+	state      *State
+	endpoints  []string
+	crossCheck bool
+	retry      *RetryPolicy
+	poll       *PollPolicy
+	store      StateStore
+	catchUp    EpochCatchUpFunc
+	cache      *Cache
+}
+
+// WithTLS makes the client connect over TLS with the given config. A nil config uses
+// the system root certificates.
+func WithTLS(config *tls.Config) Option {
+	return WithTransportCredentials(credentials.NewTLS(config))
+}
+
+// WithTransportCredentials makes the client connect with the given transport credentials.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(o *clientOptions) {
+		o.dialOpts = append(o.dialOpts, grpc.WithTransportCredentials(creds))
+		o.secure = true
+	}
+}
+
+// WithPerRPCCredentials attaches the given credentials, e.g. auth metadata, to every RPC call.
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return func(o *clientOptions) {
+		o.dialOpts = append(o.dialOpts, grpc.WithPerRPCCredentials(creds))
+	}
+}
+
+// WithKeepalive sets the keepalive parameters of the connections.
+func WithKeepalive(params keepalive.ClientParameters) Option {
+	return func(o *clientOptions) {
+		o.dialOpts = append(o.dialOpts, grpc.WithKeepaliveParams(params))
+	}
+}
+
+// WithUnaryInterceptors installs unary interceptors on the connections. Interceptors are
+// chained in the given order.
+func WithUnaryInterceptors(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(o *clientOptions) {
+		o.dialOpts = append(o.dialOpts, grpc.WithChainUnaryInterceptor(interceptors...))
+	}
+}
+
+// WithDialOptions appends raw gRPC dial options. If none of the options sets the transport
+// credentials, WithTLS or WithTransportCredentials should be used, or the connection will
+// be insecure.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *clientOptions) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+// WithConn makes the client use an already established connection, instead of dialing.
+// The connection is not closed when the client is closed.
+func WithConn(conn *grpc.ClientConn) Option {
+	return func(o *clientOptions) {
+		o.conn = conn
+	}
+}
+
+// WithAdmissionControlClient makes the client use the given AdmissionControl client, instead
+// of dialing. This is useful to wrap or mock the RPC service.
+func WithAdmissionControlClient(ac pbac.AdmissionControlClient) Option {
+	return func(o *clientOptions) {
+		o.ac = ac
+	}
+}
+
+// WithState restores the client from a previous saved state, instead of the waypoint.
+func WithState(state *State) Option {
+	return func(o *clientOptions) {
+		o.state = state
+	}
+}
+
+// WithStateStore sets the state store. See SetStateStore. Unless WithState is specified,
+// the client is restored from the latest state in the store, if any.
+func WithStateStore(s StateStore) Option {
+	return func(o *clientOptions) {
+		o.store = s
+	}
+}
+
+// WithEpochCatchUpFunc sets the function to report the progress of catching up with epochs.
+// See SetEpochCatchUpFunc.
+func WithEpochCatchUpFunc(f EpochCatchUpFunc) Option {
+	return func(o *clientOptions) {
+		o.catchUp = f
+	}
+}
+
+// WithCache sets the cache of verified immutable objects. See SetCache.
+func WithCache(cache *Cache) Option {
+	return func(o *clientOptions) {
+		o.cache = cache
+	}
+}
+
+// WithEndpoints adds more servers to fail over to. See NewMultiEndpoint.
+func WithEndpoints(addrs ...string) Option {
+	return func(o *clientOptions) {
+		o.endpoints = append(o.endpoints, addrs...)
+	}
+}
+
+// WithCrossCheck enables cross checking among servers. See SetCrossCheck.
+func WithCrossCheck() Option {
+	return func(o *clientOptions) {
+		o.crossCheck = true
+	}
+}
+
+// WithRetryPolicy sets the retry policy. See SetRetryPolicy.
+func WithRetryPolicy(p *RetryPolicy) Option {
+	return func(o *clientOptions) {
+		o.retry = p
+	}
+}
+
+// WithPollPolicy sets the poll policy. See SetPollPolicy.
+func WithPollPolicy(p *PollPolicy) Option {
+	return func(o *clientOptions) {
+		o.poll = p
+	}
+}
+
+// NewWithOptions creates a new Libra Client from a trusted waypoint, with options.
+//
+// By default, it connects to ServerAddr with an insecure connection, just like New.
+// If WithConn or WithAdmissionControlClient is specified, ServerAddr is only used to
+// identify the server, and all dialing options are ignored.
+func NewWithOptions(ServerAddr, Waypoint string, opts ...Option) (*Client, error) {
+	o := &clientOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	state := o.state
+	if state == nil && o.store != nil {
+		var err error
+		if state, err = LoadLatestState(o.store); err != nil {
+			return nil, fmt.Errorf("load state error: %v", err)
+		}
+	}
+	if state == nil {
+		state = &State{Waypoint: Waypoint}
+	}
+
+	c := &Client{crossCheck: o.crossCheck, retryPolicy: o.retry, pollPolicy: o.poll, stateStore: o.store, catchUpFunc: o.catchUp}
+	if err := c.SetState(state); err != nil {
+		return nil, fmt.Errorf("invalid state: %v", err)
+	}
+	// set after the state, so that a shared cache is not purged
+	c.cache = o.cache
+
+	switch {
+	case o.ac != nil || o.conn != nil:
+		if len(o.endpoints) > 0 {
+			return nil, errors.New("cannot use extra endpoints with an existing connection")
+		}
+		ac := o.ac
+		if ac == nil {
+			ac = pbac.NewAdmissionControlClient(o.conn)
+		}
+		c.endpoints = []*endpoint{{addr: ServerAddr, ac: ac}}
+	default:
+		dialOpts := o.dialOpts
+		if !o.secure {
+			dialOpts = append([]grpc.DialOption{grpc.WithInsecure()}, dialOpts...)
+		}
+		for _, addr := range append([]string{ServerAddr}, o.endpoints...) {
+			ep, err := dial(addr, dialOpts...)
+			if err != nil {
+				c.Close()
+				return nil, err
+			}
+			c.endpoints = append(c.endpoints, ep)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,75 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/generated/pbac"
+	"github.com/the729/go-libra/libratest"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	_, addrs := libratest.StartServers(t, l)
+
+	t.Run("interceptors", func(t *testing.T) {
+		var methods []string
+		c, err := client.NewWithOptions(addrs[0], l.Waypoint(), client.WithUnaryInterceptors(
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				methods = append(methods, method)
+				return invoker(ctx, method, req, reply, cc, opts...)
+			},
+		))
+		require.NoError(t, err)
+		defer c.Close()
+
+		_, err = c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/admission_control.AdmissionControl/UpdateToLatestLedger"}, methods)
+	})
+
+	t.Run("conn", func(t *testing.T) {
+		conn, err := grpc.Dial(addrs[0], grpc.WithInsecure())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		c, err := client.NewWithOptions("node0", l.Waypoint(), client.WithConn(conn))
+		require.NoError(t, err)
+		_, err = c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "node0", c.CurrentEndpoint())
+
+		// the injected connection is still usable after the client is closed
+		c.Close()
+		c, err = client.NewWithOptions("node0", "", client.WithConn(conn), client.WithState(c.GetState()))
+		require.NoError(t, err)
+		_, err = c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("admission control client", func(t *testing.T) {
+		conn, err := grpc.Dial(addrs[0], grpc.WithInsecure())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		c, err := client.NewWithOptions("", l.Waypoint(), client.WithAdmissionControlClient(pbac.NewAdmissionControlClient(conn)))
+		require.NoError(t, err)
+		_, err = c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		c, err := client.NewWithOptions("127.0.0.1:1", l.Waypoint(), client.WithEndpoints(addrs[0]))
+		require.NoError(t, err)
+		defer c.Close()
+		_, err = c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, addrs[0], c.CurrentEndpoint())
+	})
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/the729/go-libra/types"
+)
+
+// PaymentRequestScheme is the URI scheme of payment requests.
+const PaymentRequestScheme = "libra"
+
+// CurrencyLBR is the currency code of Libra coins, which is the only supported currency.
+const CurrencyLBR = "LBR"
+
+// MicroLibraPerLibra is the number of micro Libra in one Libra.
+const MicroLibraPerLibra = 1000000
+
+// PaymentRequest is a request of payment to a receiver account, encoded as a URI like:
+//   libra://f72589b71ff4f8d139674a3f7369c69b?am=1.5&c=LBR&memo=order+42
+//
+// The receiver in the URI is either an address of 32 hex digits, or a bech32 account
+// identifier. Query parameters are:
+//   am:   amount in Libra, with at most 6 decimal places
+//   c:    currency code, which must be LBR if present
+//   ak:   hex encoded auth key prefix of the receiver, needed if the receiver account
+//         does not exist yet
+//   sa:   hex encoded subaddress of the receiver
+//   memo: a message to be shown to the payer
+// Unknown parameters are ignored, unless they start with "req-", in which case the
+// request is rejected.
+type PaymentRequest struct {
+	Receiver              types.AccountAddress
+	SubAddress            types.SubAddress
+	ReceiverAuthKeyPrefix []byte
+
+	// Amount in micro Libra. Zero means the payer chooses the amount.
+	Amount   uint64
+	Currency string
+	Memo     string
+}
+
+// String encodes the payment request into its canonical URI.
+func (r *PaymentRequest) String() string {
+	q := make([]string, 0, 5)
+	if r.Amount != 0 {
+		q = append(q, "am="+FormatLibraAmount(r.Amount))
+	}
+	if r.Currency != "" {
+		q = append(q, "c="+url.QueryEscape(r.Currency))
+	}
+	if len(r.ReceiverAuthKeyPrefix) != 0 {
+		q = append(q, "ak="+hex.EncodeToString(r.ReceiverAuthKeyPrefix))
+	}
+	if !r.SubAddress.IsZero() {
+		q = append(q, "sa="+hex.EncodeToString(r.SubAddress[:]))
+	}
+	if r.Memo != "" {
+		q = append(q, "memo="+url.QueryEscape(r.Memo))
+	}
+	s := PaymentRequestScheme + "://" + hex.EncodeToString(r.Receiver[:])
+	if len(q) != 0 {
+		s += "?" + strings.Join(q, "&")
+	}
+	return s
+}
+
+// ParsePaymentRequest parses and validates a payment request URI.
+func ParsePaymentRequest(uri string) (*PaymentRequest, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != PaymentRequestScheme {
+		return nil, fmt.Errorf("wrong scheme %q", u.Scheme)
+	}
+	if u.Opaque != "" || u.User != nil || u.Port() != "" || (u.Path != "" && u.Path != "/") {
+		return nil, errors.New("malformed payment request")
+	}
+	r := &PaymentRequest{}
+	if r.Receiver, r.SubAddress, err = ParseAccount(u.Hostname()); err != nil {
+		return nil, fmt.Errorf("invalid receiver: %v", err)
+	}
+
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range q {
+		if len(v) != 1 {
+			return nil, fmt.Errorf("duplicated parameter %q", k)
+		}
+		switch k {
+		case "am":
+			if r.Amount, err = ParseLibraAmount(v[0]); err != nil {
+				return nil, err
+			}
+		case "c":
+			r.Currency = v[0]
+		case "ak":
+			if r.ReceiverAuthKeyPrefix, err = hex.DecodeString(v[0]); err != nil {
+				return nil, fmt.Errorf("invalid auth key prefix: %v", err)
+			}
+		case "sa":
+			sa, err := hex.DecodeString(v[0])
+			if err != nil || len(sa) != types.SubAddressLength {
+				return nil, errors.New("invalid subaddress")
+			}
+			if !r.SubAddress.IsZero() && !bytes.Equal(r.SubAddress[:], sa) {
+				return nil, errors.New("subaddress conflicts with account identifier")
+			}
+			copy(r.SubAddress[:], sa)
+		case "memo":
+			r.Memo = v[0]
+		default:
+			if strings.HasPrefix(k, "req-") {
+				return nil, fmt.Errorf("unsupported required parameter %q", k)
+			}
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Validate checks the currency and the auth key prefix of the payment request.
+func (r *PaymentRequest) Validate() error {
+	if r.Currency != "" && r.Currency != CurrencyLBR {
+		return fmt.Errorf("unsupported currency %q", r.Currency)
+	}
+	if len(r.ReceiverAuthKeyPrefix) != 0 && len(r.ReceiverAuthKeyPrefix) != types.AuthenticationKeyPrefixLength {
+		return fmt.Errorf("wrong receiver auth key prefix length %d", len(r.ReceiverAuthKeyPrefix))
+	}
+	return nil
+}
+
+// NewRawTransaction creates a raw peer-to-peer transaction which pays the request. It fails
+// if the request has no amount, or has a subaddress, which cannot be attached to a
+// peer-to-peer transaction.
+func (r *PaymentRequest) NewRawTransaction(
+	senderAddress types.AccountAddress,
+	senderSequenceNumber uint64,
+	maxGasAmount, gasUnitPrice uint64,
+	expiration time.Time,
+) (*types.RawTransaction, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if r.Amount == 0 {
+		return nil, errors.New("payment request has no amount")
+	}
+	if !r.SubAddress.IsZero() {
+		return nil, errors.New("subaddress cannot be attached to a peer-to-peer transaction")
+	}
+	return NewRawP2PTransaction(
+		senderAddress, r.Receiver, r.ReceiverAuthKeyPrefix,
+		senderSequenceNumber, r.Amount, maxGasAmount, gasUnitPrice, expiration,
+	)
+}
+
+// ParseLibraAmount parses a decimal amount in Libra, e.g. "1.5", into micro Libra.
+func ParseLibraAmount(s string) (uint64, error) {
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" || len(fracPart) > 6 || strings.IndexFunc(intPart+fracPart, notDigit) >= 0 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	libra, err := strconv.ParseUint(intPart, 10, 64)
+	if err != nil || libra > (1<<64-1)/MicroLibraPerLibra {
+		return 0, fmt.Errorf("amount %q out of range", s)
+	}
+	micro := uint64(0)
+	if fracPart != "" {
+		micro, _ = strconv.ParseUint(fracPart+strings.Repeat("0", 6-len(fracPart)), 10, 64)
+	}
+	amount := libra*MicroLibraPerLibra + micro
+	if amount < micro {
+		return 0, fmt.Errorf("amount %q out of range", s)
+	}
+	return amount, nil
+}
+
+// FormatLibraAmount formats an amount in micro Libra into a decimal amount in Libra,
+// without trailing zeros, e.g. "1.5".
+func FormatLibraAmount(amount uint64) string {
+	s := strconv.FormatUint(amount/MicroLibraPerLibra, 10)
+	if frac := amount % MicroLibraPerLibra; frac != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%06d", frac), "0")
+	}
+	return s
+}
+
+func notDigit(r rune) bool {
+	return r < '0' || r > '9'
+}
@@ -0,0 +1,116 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestLibraAmount(t *testing.T) {
+	for s, amount := range map[string]uint64{
+		"0":        0,
+		"1":        1000000,
+		"1.5":      1500000,
+		"0.000001": 1,
+		"12.34":    12340000,
+	} {
+		a, err := client.ParseLibraAmount(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, amount, a, s)
+		assert.Equal(t, s, client.FormatLibraAmount(amount))
+	}
+	for _, s := range []string{"", ".5", "-1", "1.0000001", "1e6", "0x10", "18446744073709.551616", "99999999999999999999"} {
+		_, err := client.ParseLibraAmount(s)
+		assert.Error(t, err, s)
+	}
+	a, err := client.ParseLibraAmount("18446744073709.551615")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1<<64-1), a)
+}
+
+func TestPaymentRequest(t *testing.T) {
+	addr := client.MustToAddress("f72589b71ff4f8d139674a3f7369c69b")
+
+	t.Run("round trip", func(t *testing.T) {
+		r := &client.PaymentRequest{
+			Receiver:              addr,
+			SubAddress:            types.SubAddress{1, 2, 3, 4, 5, 6, 7, 8},
+			ReceiverAuthKeyPrefix: make([]byte, types.AuthenticationKeyPrefixLength),
+			Amount:                1500000,
+			Currency:              client.CurrencyLBR,
+			Memo:                  "order #42 & more",
+		}
+		uri := r.String()
+		assert.Equal(t, "libra://f72589b71ff4f8d139674a3f7369c69b?am=1.5&c=LBR&ak=00000000000000000000000000000000&sa=0102030405060708&memo=order+%2342+%26+more", uri)
+		parsed, err := client.ParsePaymentRequest(uri)
+		require.NoError(t, err)
+		assert.Equal(t, r, parsed)
+		assert.Equal(t, "libra://f72589b71ff4f8d139674a3f7369c69b", (&client.PaymentRequest{Receiver: addr}).String())
+	})
+
+	t.Run("account identifier", func(t *testing.T) {
+		r, err := client.ParsePaymentRequest("libra://lbr1p7ujcndcl7nudzwt8fglhx6wxn08kgs5tm6mz4usw5p72t?am=2&unknown=1")
+		require.NoError(t, err)
+		assert.Equal(t, addr, r.Receiver)
+		assert.Equal(t, types.SubAddress{0xcf, 0x64, 0x42, 0x8b, 0xde, 0xb6, 0x2a, 0xf2}, r.SubAddress)
+		assert.Equal(t, uint64(2000000), r.Amount)
+
+		_, err = client.ParsePaymentRequest("libra://lbr1p7ujcndcl7nudzwt8fglhx6wxn08kgs5tm6mz4usw5p72t?sa=0102030405060708")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, uri := range []string{
+			"bitcoin://f72589b71ff4f8d139674a3f7369c69b",
+			"libra:f72589b71ff4f8d139674a3f7369c69b",
+			"libra://f72589b71ff4f8d139674a3f7369c6",
+			"libra://f72589b71ff4f8d139674a3f7369c69b/pay",
+			"libra://f72589b71ff4f8d139674a3f7369c69b?am=1&am=2",
+			"libra://f72589b71ff4f8d139674a3f7369c69b?am=-1",
+			"libra://f72589b71ff4f8d139674a3f7369c69b?c=USD",
+			"libra://f72589b71ff4f8d139674a3f7369c69b?ak=00",
+			"libra://f72589b71ff4f8d139674a3f7369c69b?sa=zz",
+			"libra://f72589b71ff4f8d139674a3f7369c69b?req-expires=1",
+		} {
+			_, err := client.ParsePaymentRequest(uri)
+			assert.Error(t, err, uri)
+		}
+	})
+}
+
+func TestPayPaymentRequest(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	_, c := libratest.StartServer(t, l)
+	c.SetPollPolicy(&client.PollPolicy{Interval: 10 * time.Millisecond})
+
+	r, err := client.ParsePaymentRequest((&client.PaymentRequest{Receiver: bob.Address}).String() + "?am=0.00001&c=LBR")
+	require.NoError(t, err)
+	rawTxn, err := r.NewRawTransaction(alice.Address, 0, 10000, 0, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	ptxn, err := c.SubmitAndWait(ctx, rawTxn, alice.PrivateKey)
+	require.NoError(t, err)
+	assert.Equal(t, types.EXECUTED, ptxn.GetMajorStatus())
+
+	state, err := c.QueryAccountState(ctx, bob.Address)
+	require.NoError(t, err)
+	br, err := state.GetAccountBlob().GetLibraBalanceResource()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), br.Coin)
+
+	r.Amount = 0
+	_, err = r.NewRawTransaction(alice.Address, 1, 10000, 0, time.Now().Add(time.Minute))
+	assert.Error(t, err)
+	r.Amount, r.SubAddress = 10, types.SubAddress{1}
+	_, err = r.NewRawTransaction(alice.Address, 1, 10000, 0, time.Now().Add(time.Minute))
+	assert.Error(t, err)
+}
@@ -12,36 +12,36 @@ import (
 // QueryAccountState queries account state from RPC server by account address, and does necessary
 // crypto verifications.
 func (c *Client) QueryAccountState(ctx context.Context, addr types.AccountAddress) (*types.ProvenAccountState, error) {
-	c.accMu.RLock()
-	frozenSubtreeRoots := cloneSubtrees(c.acc.FrozenSubtreeRoots)
-	numLeaves := c.acc.NumLeaves
-	c.accMu.RUnlock()
-
-	resp, err := c.ac.UpdateToLatestLedger(ctx, &pbtypes.UpdateToLatestLedgerRequest{
-		ClientKnownVersion: numLeaves - 1,
-		RequestedItems: []*pbtypes.RequestItem{
-			&pbtypes.RequestItem{
-				RequestedItems: &pbtypes.RequestItem_GetAccountStateRequest{
-					GetAccountStateRequest: &pbtypes.GetAccountStateRequest{
-						Address: addr[:],
-					},
-				},
-			},
-		},
-	})
+	resp, pli, err := c.updateToLatestLedger(ctx, []*pbtypes.RequestItem{accountStateRequest(addr)})
 	if err != nil {
 		return nil, err
 	}
-	// respj, _ := json.MarshalIndent(resp, "", "    ")
-	// log.Println(string(respj))
+	return verifyAccountState(resp.ResponseItems[0], addr, pli)
+}
 
-	pli, err := c.verifyLedgerInfoAndConsistency(resp, numLeaves, frozenSubtreeRoots)
+// QueryAccountSequenceNumber queries sequence number of an account from RPC server, and does necessary
+// crypto verifications.
+func (c *Client) QueryAccountSequenceNumber(ctx context.Context, addr types.AccountAddress) (uint64, error) {
+	paccount, err := c.QueryAccountState(ctx, addr)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
+	return accountSequenceNumber(paccount)
+}
 
+func accountStateRequest(addr types.AccountAddress) *pbtypes.RequestItem {
+	return &pbtypes.RequestItem{
+		RequestedItems: &pbtypes.RequestItem_GetAccountStateRequest{
+			GetAccountStateRequest: &pbtypes.GetAccountStateRequest{
+				Address: addr[:],
+			},
+		},
+	}
+}
+
+func verifyAccountState(item *pbtypes.ResponseItem, addr types.AccountAddress, pli *types.ProvenLedgerInfo) (*types.ProvenAccountState, error) {
 	account := &types.AccountStateWithProof{}
-	err = account.FromProtoResponse(resp.ResponseItems[0].GetGetAccountStateResponse())
+	err := account.FromProtoResponse(item.GetGetAccountStateResponse())
 	if err != nil {
 		return nil, fmt.Errorf("account state with proof from proto failed: %v", err)
 	}
@@ -54,13 +54,7 @@ func (c *Client) QueryAccountState(ctx context.Context, addr types.AccountAddres
 	return paccount, nil
 }
 
-// QueryAccountSequenceNumber queries sequence number of an account from RPC server, and does necessary
-// crypto verifications.
-func (c *Client) QueryAccountSequenceNumber(ctx context.Context, addr types.AccountAddress) (uint64, error) {
-	paccount, err := c.QueryAccountState(ctx, addr)
-	if err != nil {
-		return 0, err
-	}
+func accountSequenceNumber(paccount *types.ProvenAccountState) (uint64, error) {
 	if paccount.IsNil() {
 		return 0, errors.New("sender account not present in ledger")
 	}
@@ -10,61 +10,77 @@ import (
 )
 
 // QueryEventsByAccessPath queries list of events by access path does necessary crypto verifications.
+//
+// See QueryEventListByAccessPath for the completeness of the events.
 func (c *Client) QueryEventsByAccessPath(ctx context.Context, ap *types.AccessPath, start uint64, ascending bool, limit uint64) ([]*types.ProvenEvent, error) {
-	c.accMu.RLock()
-	frozenSubtreeRoots := cloneSubtrees(c.acc.FrozenSubtreeRoots)
-	numLeaves := c.acc.NumLeaves
-	c.accMu.RUnlock()
-
-	resp, err := c.ac.UpdateToLatestLedger(ctx, &pbtypes.UpdateToLatestLedgerRequest{
-		ClientKnownVersion: numLeaves - 1,
-		RequestedItems: []*pbtypes.RequestItem{
-			{
-				RequestedItems: &pbtypes.RequestItem_GetEventsByEventAccessPathRequest{
-					GetEventsByEventAccessPathRequest: &pbtypes.GetEventsByEventAccessPathRequest{
-						AccessPath: &pbtypes.AccessPath{
-							Address: ap.Address[:],
-							Path:    ap.Path,
-						},
-						StartEventSeqNum: start,
-						Ascending:        ascending,
-						Limit:            limit,
-					},
-				},
-			},
-		},
-	})
+	pel, err := c.QueryEventListByAccessPath(ctx, ap, start, ascending, limit)
 	if err != nil {
 		return nil, err
 	}
+	return pel.GetEvents(), nil
+}
 
-	pli, err := c.verifyLedgerInfoAndConsistency(resp, numLeaves, frozenSubtreeRoots)
+// QueryEventListByAccessPath queries list of events by access path does necessary crypto
+// verifications, and outputs the proven event list.
+//
+// For the sent and received event paths of an account, the event list is proven to be
+// complete, i.e. the server has not withheld any events in the requested range. The total
+// number of events under the access path is also proven. For other paths, the list is not
+// proven complete. See types.ProvenEventList.IsComplete.
+//
+// With a cache, the result may be proven by an earlier ledger info, and so is the total number
+// of events. See SetCache.
+func (c *Client) QueryEventListByAccessPath(ctx context.Context, ap *types.AccessPath, start uint64, ascending bool, limit uint64) (*types.ProvenEventList, error) {
+	cache := c.getCache()
+	if pel := cache.getEvents(ap, start, ascending, limit); pel != nil {
+		return pel, nil
+	}
+	resp, pli, err := c.updateToLatestLedger(ctx, []*pbtypes.RequestItem{eventsByAccessPathRequest(ap, start, ascending, limit)})
 	if err != nil {
 		return nil, err
 	}
+	pel, err := verifyEventsByAccessPath(resp.ResponseItems[0], ap, start, ascending, limit, pli)
+	if err != nil {
+		return nil, err
+	}
+	cache.addEvents(ap, pel)
+	return pel, nil
+}
 
-	resp1 := resp.ResponseItems[0].GetGetEventsByEventAccessPathResponse()
+func eventsByAccessPathRequest(ap *types.AccessPath, start uint64, ascending bool, limit uint64) *pbtypes.RequestItem {
+	return &pbtypes.RequestItem{
+		RequestedItems: &pbtypes.RequestItem_GetEventsByEventAccessPathRequest{
+			GetEventsByEventAccessPathRequest: &pbtypes.GetEventsByEventAccessPathRequest{
+				AccessPath: &pbtypes.AccessPath{
+					Address: ap.Address[:],
+					Path:    ap.Path,
+				},
+				StartEventSeqNum: start,
+				Ascending:        ascending,
+				Limit:            limit,
+			},
+		},
+	}
+}
+
+func verifyEventsByAccessPath(
+	item *pbtypes.ResponseItem, ap *types.AccessPath,
+	start uint64, ascending bool, limit uint64, pli *types.ProvenLedgerInfo,
+) (*types.ProvenEventList, error) {
+	resp1 := item.GetGetEventsByEventAccessPathResponse()
 	if resp1 == nil {
 		return nil, errors.New("nil response")
 	}
 	// b, err := json.MarshalIndent(resp1, "", "    ")
 	// log.Printf("resp1: %s", string(b))
 
-	if len(resp1.EventsWithProof) < int(limit) {
-		//TODO: verify resp1.ProofOfLatestEvent
+	el := &types.EventListWithProof{}
+	if err := el.FromProto(resp1); err != nil {
+		return nil, err
 	}
-	pevs := make([]*types.ProvenEvent, 0, len(resp1.EventsWithProof))
-	for _, pbev := range resp1.EventsWithProof {
-		ev := &types.EventWithProof{}
-		if err := ev.FromProto(pbev); err != nil {
-			return nil, fmt.Errorf("event from protobuf error: %v", err)
-		}
-		pev, err := ev.Verify(pli)
-		if err != nil {
-			return nil, fmt.Errorf("event verification error: %v", err)
-		}
-		pevs = append(pevs, pev)
+	pel, err := el.VerifyList(ap, start, ascending, limit, pli)
+	if err != nil {
+		return nil, fmt.Errorf("event list verification error: %v", err)
 	}
-
-	return pevs, nil
+	return pel, nil
 }
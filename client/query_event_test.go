@@ -0,0 +1,117 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestQueryEventsByAccessPath(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	s, c := libratest.StartServer(t, l)
+	for seq := uint64(0); seq < 3; seq++ {
+		libratest.Transfer(t, c, alice, bob, seq, 10)
+	}
+	ap := &types.AccessPath{Address: bob.Address, Path: types.AccountReceivedEventPath()}
+
+	t.Run("complete", func(t *testing.T) {
+		for _, tc := range []struct {
+			start     uint64
+			ascending bool
+			limit     uint64
+			seqs      []uint64
+		}{
+			{0, true, 10, []uint64{0, 1, 2}},
+			{1, true, 1, []uint64{1}},
+			{3, true, 10, nil},
+			{^uint64(0), false, 2, []uint64{2, 1}},
+			{0, false, 10, []uint64{0}},
+		} {
+			pel, err := c.QueryEventListByAccessPath(ctx, ap, tc.start, tc.ascending, tc.limit)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(3), pel.GetTotalCount())
+			var seqs []uint64
+			for _, pev := range pel.GetEvents() {
+				seqs = append(seqs, pev.GetEvent().Value.(*types.ContractEventV0).SequenceNumber)
+			}
+			assert.Equal(t, tc.seqs, seqs)
+		}
+
+		pel, err := c.QueryEventListByAccessPath(ctx, &types.AccessPath{Address: types.AccountAddress{1}, Path: types.AccountSentEventPath()}, 0, true, 10)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), pel.GetTotalCount())
+
+		pevs, err := c.QueryEventsByAccessPath(ctx, ap, 0, true, 10)
+		require.NoError(t, err)
+		assert.Len(t, pevs, 3)
+	})
+
+	t.Run("unknown handle", func(t *testing.T) {
+		var evs []*pbtypes.EventWithProof
+		s.TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			evs = resp.ResponseItems[0].GetGetEventsByEventAccessPathResponse().EventsWithProof
+		}
+		_, err := c.QueryEventListByAccessPath(ctx, ap, 0, true, 10)
+		require.NoError(t, err)
+		require.Len(t, evs, 3)
+
+		// serve the received events under another path of the account
+		other := &types.AccessPath{Address: bob.Address, Path: []byte("other")}
+		s.TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			resp.ResponseItems[0].GetGetEventsByEventAccessPathResponse().EventsWithProof = evs
+		}
+		defer func() { s.TamperResponse = nil }()
+		pel, err := c.QueryEventListByAccessPath(ctx, other, 0, true, 10)
+		require.NoError(t, err)
+		assert.False(t, pel.IsComplete())
+		assert.Len(t, pel.GetEvents(), 3)
+
+		_, err = c.QueryEventListByAccessPath(ctx, other, 1, true, 10)
+		assert.Error(t, err)
+		_, err = c.QueryEventListByAccessPath(ctx, other, 0, true, 2)
+		assert.Error(t, err)
+		evs[0], evs[1] = evs[1], evs[0]
+		_, err = c.QueryEventListByAccessPath(ctx, other, 0, true, 10)
+		assert.Error(t, err)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		tamper func(*pbtypes.GetEventsByEventAccessPathResponse)
+	}{
+		{"truncated", func(resp *pbtypes.GetEventsByEventAccessPathResponse) {
+			resp.EventsWithProof = resp.EventsWithProof[:len(resp.EventsWithProof)-1]
+		}},
+		{"withheld", func(resp *pbtypes.GetEventsByEventAccessPathResponse) {
+			resp.EventsWithProof = nil
+		}},
+		{"reordered", func(resp *pbtypes.GetEventsByEventAccessPathResponse) {
+			evs := resp.EventsWithProof
+			evs[0], evs[1] = evs[1], evs[0]
+		}},
+		{"stale proof", func(resp *pbtypes.GetEventsByEventAccessPathResponse) {
+			resp.ProofOfLatestEvent.Version--
+		}},
+		{"missing proof", func(resp *pbtypes.GetEventsByEventAccessPathResponse) {
+			resp.ProofOfLatestEvent = nil
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s.TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+				tc.tamper(resp.ResponseItems[0].GetGetEventsByEventAccessPathResponse())
+			}
+			defer func() { s.TamperResponse = nil }()
+			_, err := c.QueryEventListByAccessPath(ctx, ap, 0, true, 10)
+			assert.Error(t, err)
+		})
+	}
+}
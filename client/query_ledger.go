@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/the729/go-libra/generated/pbtypes"
@@ -10,72 +11,165 @@ import (
 
 // QueryLedgerInfo queries ledger info from RPC server, and does necessary crypto verifications.
 func (c *Client) QueryLedgerInfo(ctx context.Context) (*types.ProvenLedgerInfo, error) {
+	_, pli, err := c.updateToLatestLedger(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return pli, nil
+}
+
+// errMoreEpochChanges is returned when the validator change proof in a response is only the
+// first page, and the ledger info cannot be verified until the remaining epochs are caught up.
+var errMoreEpochChanges = errors.New("more epoch changes to catch up")
+
+// updateToLatestLedger sends an UpdateToLatestLedger request with the requested items, and
+// verifies the ledger info and its consistency with the known version.
+//
+// If the client starts from a waypoint other than the genesis, it bootstraps the accumulator
+// at the waypoint version first. If the client is behind by more epochs than a validator
+// change proof carries, it catches up with the epochs, and sends the request again. It fails
+// if a catch up does not advance the trusted epoch.
+//
+// It makes sure that there is exactly one response item for each requested item, but the
+// response items are not verified.
+func (c *Client) updateToLatestLedger(ctx context.Context, items []*pbtypes.RequestItem) (*pbtypes.UpdateToLatestLedgerResponse, *types.ProvenLedgerInfo, error) {
+	if wp := c.needBootstrap(); wp != nil {
+		if err := c.bootstrap(ctx, wp); err != nil {
+			return nil, nil, fmt.Errorf("bootstrap from waypoint error: %v", err)
+		}
+	}
+	for {
+		c.accMu.RLock()
+		lastWaypoint := c.lastWaypoint
+		c.accMu.RUnlock()
+		resp, pli, err := c.updateToLatestLedgerOnce(ctx, items)
+		if err != errMoreEpochChanges {
+			return resp, pli, err
+		}
+		if err := c.catchUpEpochs(ctx); err != nil {
+			return nil, nil, err
+		}
+		// a server may keep sending more epoch changes, which are never caught up
+		c.accMu.RLock()
+		advanced := c.lastWaypoint != lastWaypoint
+		c.accMu.RUnlock()
+		if !advanced {
+			return nil, nil, errors.New("epoch catch up made no progress, though more epoch changes are reported")
+		}
+	}
+}
+
+func (c *Client) updateToLatestLedgerOnce(ctx context.Context, items []*pbtypes.RequestItem) (*pbtypes.UpdateToLatestLedgerResponse, *types.ProvenLedgerInfo, error) {
 	c.accMu.RLock()
 	frozenSubtreeRoots := cloneSubtrees(c.acc.FrozenSubtreeRoots)
 	numLeaves := c.acc.NumLeaves
 	c.accMu.RUnlock()
 
-	resp, err := c.ac.UpdateToLatestLedger(ctx, &pbtypes.UpdateToLatestLedgerRequest{
+	req := &pbtypes.UpdateToLatestLedgerRequest{
 		ClientKnownVersion: numLeaves - 1,
-	})
-	if err != nil {
-		return nil, err
+		RequestedItems:     items,
 	}
 
-	// respj, _ := json.MarshalIndent(resp, "", "    ")
-	// log.Println(string(respj))
+	c.epMu.Lock()
+	crossCheck := c.crossCheck && len(c.endpoints) > 1
+	c.epMu.Unlock()
 
-	pli, err := c.verifyLedgerInfoAndConsistency(resp, numLeaves, frozenSubtreeRoots)
-	if err != nil {
-		return nil, err
+	var resp *pbtypes.UpdateToLatestLedgerResponse
+	var pli *types.ProvenLedgerInfo
+	var err error
+	if crossCheck {
+		err = c.withRetry(ctx, false, func(int) error {
+			resp, pli, err = c.fanOut(ctx, req, numLeaves, frozenSubtreeRoots)
+			return err
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		err = c.withRetry(ctx, false, func(int) error {
+			return c.withFailover(ctx, func(ep *endpoint) error {
+				resp, err = ep.ac.UpdateToLatestLedger(ctx, req)
+				return err
+			})
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// respj, _ := json.MarshalIndent(resp, "", "    ")
+		// log.Println(string(respj))
+
+		pli, err = c.verifyLedgerInfoAndConsistency(resp, numLeaves, frozenSubtreeRoots)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(resp.ResponseItems) != len(items) {
+		return nil, nil, fmt.Errorf("mismatch length: %d requested items, %d response items", len(items), len(resp.ResponseItems))
 	}
 
-	return pli, nil
+	return resp, pli, nil
 }
 
 func (c *Client) verifyLedgerInfoAndConsistency(
 	resp *pbtypes.UpdateToLatestLedgerResponse,
 	numLeaves uint64, frozenSubtreeRoots [][]byte,
 ) (*types.ProvenLedgerInfo, error) {
+	pli, update, err := c.verifyLedgerInfo(resp, numLeaves, frozenSubtreeRoots)
+	if err != nil {
+		return nil, err
+	}
+	if c.applyLedgerUpdate(update) {
+		if err := c.saveState(); err != nil {
+			return nil, err
+		}
+	}
+	return pli, nil
+}
+
+// ledgerUpdate is the new trusted state of a client, after a ledger info is verified.
+type ledgerUpdate struct {
+	numLeaves          uint64
+	frozenSubtreeRoots [][]byte
+	verifier           types.LedgerInfoVerifier
+	lastWaypoint       string
+	epochChange        *types.ProvenLedgerInfo
+}
+
+// verifyLedgerInfo verifies the ledger info and its consistency with the known version,
+// without updating the trusted state of the client.
+func (c *Client) verifyLedgerInfo(
+	resp *pbtypes.UpdateToLatestLedgerResponse,
+	numLeaves uint64, frozenSubtreeRoots [][]byte,
+) (*types.ProvenLedgerInfo, *ledgerUpdate, error) {
 
 	li := &types.LedgerInfoWithSignatures{}
 	if err := li.FromProto(resp.LedgerInfoWithSigs); err != nil {
-		return nil, fmt.Errorf("unmarshal ledgerInfoWithSigs error: %v", err)
+		return nil, nil, fmt.Errorf("unmarshal ledgerInfoWithSigs error: %v", err)
 	}
 	li0 := li.Value.(*types.LedgerInfoWithSignaturesV0)
 
+	c.accMu.RLock()
 	verifier := c.verifier
+	c.accMu.RUnlock()
 	lastWaypoint := ""
+	var epochChangeLI *types.ProvenLedgerInfo
 	if verifier.EpochChangeVerificationRequired(li0.Epoch) {
-		vcp := &types.ValidatorChangeProof{}
-		if err := vcp.FromProto(resp.ValidatorChangeProof); err != nil {
-			return nil, fmt.Errorf("validator change proof invalid: %v", err)
-		}
-		epochChange, err := vcp.Verify(verifier)
+		u, more, err := verifyValidatorChange(verifier, resp.ValidatorChangeProof)
 		if err != nil {
-			return nil, fmt.Errorf("validator change proof verification error: %v", err)
+			return nil, nil, err
 		}
-		if genesisHash := epochChange.GetGenesisHash(); genesisHash != nil {
-			// this is the genesis block, update accumulator
-			numLeaves = 1
-			frozenSubtreeRoots = [][]byte{genesisHash}
+		if more && u.verifier.EpochChangeVerificationRequired(li0.Epoch) {
+			return nil, nil, errMoreEpochChanges
 		}
-		pli := epochChange.GetLastLedgerInfo()
-		v, err := pli.ToVerifier()
-		if err != nil {
-			return nil, err
+		if u.frozenSubtreeRoots != nil {
+			numLeaves, frozenSubtreeRoots = u.numLeaves, u.frozenSubtreeRoots
 		}
-
-		// log.Printf("Ledger info verifier updated, epoch = %d, version = %d", pli.GetEpochNum()+1, pli.GetVersion())
-		// spew.Dump(v)
-
-		verifier = v
-		lastWaypointB, _ := (&types.Waypoint{}).FromProvenLedgerInfo(pli).MarshalText()
-		lastWaypoint = string(lastWaypointB)
+		verifier, epochChangeLI, lastWaypoint = u.verifier, u.epochChange, u.lastWaypoint
 	}
 	pli, err := li0.Verify(verifier)
 	if err != nil {
-		return nil, fmt.Errorf("ledger info verification failed: %v", err)
+		return nil, nil, fmt.Errorf("ledger info verification failed: %v", err)
 	}
 	if frozenSubtreeRoots != nil {
 		numLeaves, frozenSubtreeRoots, err = pli.VerifyConsistency(
@@ -84,21 +178,67 @@ func (c *Client) verifyLedgerInfoAndConsistency(
 			resp.GetLedgerConsistencyProof().GetSubtrees(),
 		)
 		if err != nil {
-			return nil, fmt.Errorf("ledger not consistent with known version: %v", err)
+			return nil, nil, fmt.Errorf("ledger not consistent with known version: %v", err)
 		}
 	} else {
 		numLeaves = pli.GetVersion() + 1
 	}
 
+	return pli, &ledgerUpdate{
+		numLeaves:          numLeaves,
+		frozenSubtreeRoots: frozenSubtreeRoots,
+		verifier:           verifier,
+		lastWaypoint:       lastWaypoint,
+		epochChange:        epochChangeLI,
+	}, nil
+}
+
+// verifyValidatorChange verifies a validator change proof, and returns the new trusted
+// verifier. It also returns whether there are more epoch changes than the proof carries.
+func verifyValidatorChange(verifier types.LedgerInfoVerifier, pb *pbtypes.ValidatorChangeProof) (*ledgerUpdate, bool, error) {
+	vcp := &types.ValidatorChangeProof{}
+	if err := vcp.FromProto(pb); err != nil {
+		return nil, false, fmt.Errorf("validator change proof invalid: %v", err)
+	}
+	epochChange, err := vcp.Verify(verifier)
+	if err == types.ErrNoNewValidatorChange && vcp.More {
+		// the page is behind the verifier, which is ahead of the known version
+		return nil, true, errMoreEpochChanges
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("validator change proof verification error: %v", err)
+	}
+	u := &ledgerUpdate{}
+	if genesisHash := epochChange.GetGenesisHash(); genesisHash != nil {
+		// this is the genesis block, update accumulator
+		u.numLeaves = 1
+		u.frozenSubtreeRoots = [][]byte{genesisHash}
+	}
+	pli := epochChange.GetLastLedgerInfo()
+	if u.verifier, err = pli.ToVerifier(); err != nil {
+		return nil, false, err
+	}
+	u.epochChange = pli
+	lastWaypointB, _ := (&types.Waypoint{}).FromProvenLedgerInfo(pli).MarshalText()
+	u.lastWaypoint = string(lastWaypointB)
+	return u, vcp.More, nil
+}
+
+// applyLedgerUpdate updates the trusted state of the client, if the update is newer.
+// It returns whether the known version or the epoch is advanced.
+func (c *Client) applyLedgerUpdate(u *ledgerUpdate) bool {
+	advanced := false
 	c.accMu.Lock()
-	if numLeaves > c.acc.NumLeaves {
-		c.acc.FrozenSubtreeRoots, c.acc.NumLeaves = frozenSubtreeRoots, numLeaves
+	if u.numLeaves > c.acc.NumLeaves {
+		c.acc.FrozenSubtreeRoots, c.acc.NumLeaves = u.frozenSubtreeRoots, u.numLeaves
+		advanced = true
 	}
-	if lastWaypoint != "" {
-		c.verifier = verifier
-		c.lastWaypoint = lastWaypoint
+	if u.lastWaypoint != "" && c.verifier.EpochChangeVerificationRequired(u.epochChange.GetEpochNum()+1) {
+		c.verifier = u.verifier
+		c.lastWaypoint = u.lastWaypoint
+		c.lastEpochChange = u.epochChange
+		advanced = true
 	}
 	c.accMu.Unlock()
-
-	return pli, nil
+	return advanced
 }
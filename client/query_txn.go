@@ -11,40 +11,95 @@ import (
 
 // QueryTransactionRange queries a list of transactions from RPC server, and does necessary
 // crypto verifications.
+//
+// With a cache, the result may be proven by an earlier ledger info. See SetCache.
 func (c *Client) QueryTransactionRange(ctx context.Context, start, limit uint64, withEvents bool) (*types.ProvenTransactionList, error) {
-	c.accMu.RLock()
-	frozenSubtreeRoots := cloneSubtrees(c.acc.FrozenSubtreeRoots)
-	numLeaves := c.acc.NumLeaves
-	c.accMu.RUnlock()
-
-	resp, err := c.ac.UpdateToLatestLedger(ctx, &pbtypes.UpdateToLatestLedgerRequest{
-		ClientKnownVersion: numLeaves - 1,
-		RequestedItems: []*pbtypes.RequestItem{
-			{
-				RequestedItems: &pbtypes.RequestItem_GetTransactionsRequest{
-					GetTransactionsRequest: &pbtypes.GetTransactionsRequest{
-						StartVersion: start,
-						Limit:        limit,
-						FetchEvents:  withEvents,
-					},
-				},
-			},
-		},
-	})
+	cache := c.getCache()
+	if ptl := cache.getTransactionRange(start, limit, withEvents); ptl != nil {
+		return ptl, nil
+	}
+	resp, pli, err := c.updateToLatestLedger(ctx, []*pbtypes.RequestItem{transactionRangeRequest(start, limit, withEvents)})
+	if err != nil {
+		return nil, err
+	}
+	ptl, err := verifyTransactionRange(resp.ResponseItems[0], pli)
 	if err != nil {
 		return nil, err
 	}
+	cache.addTransactionList(ptl)
+	return ptl, nil
+}
 
-	// b, err := json.MarshalIndent(resp, "", "    ")
-	// log.Printf("resp: %s", string(b))
+// QueryTransactionListWithProof queries a list of transactions like QueryTransactionRange,
+// and also returns the list with its accumulator range proof, which has been verified.
+// It is useful to keep a local copy of the ledger, with its own accumulator.
+func (c *Client) QueryTransactionListWithProof(ctx context.Context, start, limit uint64, withEvents bool) (*types.TransactionListWithProof, *types.ProvenTransactionList, error) {
+	resp, pli, err := c.updateToLatestLedger(ctx, []*pbtypes.RequestItem{transactionRangeRequest(start, limit, withEvents)})
+	if err != nil {
+		return nil, nil, err
+	}
+	txnList := &types.TransactionListWithProof{}
+	if err := txnList.FromProtoResponse(resp.ResponseItems[0].GetGetTransactionsResponse()); err != nil {
+		return nil, nil, err
+	}
+	ptl, err := txnList.Verify(pli)
+	if err != nil {
+		return nil, nil, fmt.Errorf("transaction list verification failed: %v", err)
+	}
+	return txnList, ptl, nil
+}
 
-	pli, err := c.verifyLedgerInfoAndConsistency(resp, numLeaves, frozenSubtreeRoots)
+// QueryTransactionByAccountSeq queries the transaction that is sent from a specific account at a specific sequence number,
+// and does necessary crypto verifications.
+//
+// If the transaction is proven not to be in the ledger yet, a *TransactionNotFoundError is returned.
+//
+// With a cache, the result may be proven by an earlier ledger info. See SetCache.
+func (c *Client) QueryTransactionByAccountSeq(ctx context.Context, addr types.AccountAddress, sequence uint64, withEvents bool) (*types.ProvenTransaction, error) {
+	cache := c.getCache()
+	if ptxn := cache.getTransactionByAccountSeq(addr, sequence, withEvents); ptxn != nil {
+		return ptxn, nil
+	}
+	resp, pli, err := c.updateToLatestLedger(ctx, []*pbtypes.RequestItem{transactionByAccountSeqRequest(addr, sequence, withEvents)})
+	if err != nil {
+		return nil, err
+	}
+	ptxn, err := verifyTransactionByAccountSeq(resp.ResponseItems[0], addr, sequence, withEvents, pli)
 	if err != nil {
 		return nil, err
 	}
+	cache.addTransactionByAccountSeq(ptxn)
+	return ptxn, nil
+}
+
+// TransactionNotFoundError is returned when a transaction of an account at a sequence number
+// is proven not to be in the ledger, because the sequence number of the account is not greater.
+type TransactionNotFoundError struct {
+	Address               types.AccountAddress
+	SequenceNumber        uint64
+	AccountSequenceNumber uint64
+	LedgerInfo            *types.ProvenLedgerInfo
+}
+
+func (e *TransactionNotFoundError) Error() string {
+	return fmt.Sprintf("sequence too large, should < %v", e.AccountSequenceNumber)
+}
+
+func transactionRangeRequest(start, limit uint64, withEvents bool) *pbtypes.RequestItem {
+	return &pbtypes.RequestItem{
+		RequestedItems: &pbtypes.RequestItem_GetTransactionsRequest{
+			GetTransactionsRequest: &pbtypes.GetTransactionsRequest{
+				StartVersion: start,
+				Limit:        limit,
+				FetchEvents:  withEvents,
+			},
+		},
+	}
+}
 
+func verifyTransactionRange(item *pbtypes.ResponseItem, pli *types.ProvenLedgerInfo) (*types.ProvenTransactionList, error) {
 	txnList := &types.TransactionListWithProof{}
-	err = txnList.FromProtoResponse(resp.ResponseItems[0].GetGetTransactionsResponse())
+	err := txnList.FromProtoResponse(item.GetGetTransactionsResponse())
 	if err != nil {
 		return nil, err
 	}
@@ -59,48 +114,30 @@ func (c *Client) QueryTransactionRange(ctx context.Context, start, limit uint64,
 	return ptl, nil
 }
 
-// QueryTransactionByAccountSeq queries the transaction that is sent from a specific account at a specific sequence number,
-// and does necessary crypto verifications.
-func (c *Client) QueryTransactionByAccountSeq(ctx context.Context, addr types.AccountAddress, sequence uint64, withEvents bool) (*types.ProvenTransaction, error) {
-	c.accMu.RLock()
-	frozenSubtreeRoots := cloneSubtrees(c.acc.FrozenSubtreeRoots)
-	numLeaves := c.acc.NumLeaves
-	c.accMu.RUnlock()
-
-	resp, err := c.ac.UpdateToLatestLedger(ctx, &pbtypes.UpdateToLatestLedgerRequest{
-		ClientKnownVersion: numLeaves - 1,
-		RequestedItems: []*pbtypes.RequestItem{
-			{
-				RequestedItems: &pbtypes.RequestItem_GetAccountTransactionBySequenceNumberRequest{
-					GetAccountTransactionBySequenceNumberRequest: &pbtypes.GetAccountTransactionBySequenceNumberRequest{
-						Account:        addr[:],
-						SequenceNumber: sequence,
-						FetchEvents:    withEvents,
-					},
-				},
+func transactionByAccountSeqRequest(addr types.AccountAddress, sequence uint64, withEvents bool) *pbtypes.RequestItem {
+	return &pbtypes.RequestItem{
+		RequestedItems: &pbtypes.RequestItem_GetAccountTransactionBySequenceNumberRequest{
+			GetAccountTransactionBySequenceNumberRequest: &pbtypes.GetAccountTransactionBySequenceNumberRequest{
+				Account:        addr[:],
+				SequenceNumber: sequence,
+				FetchEvents:    withEvents,
 			},
 		},
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	// b, err := json.MarshalIndent(resp, "", "    ")
-	// log.Printf("resp: %s", string(b))
-
-	pli, err := c.verifyLedgerInfoAndConsistency(resp, numLeaves, frozenSubtreeRoots)
-	if err != nil {
-		return nil, err
 	}
+}
 
-	resp1 := resp.ResponseItems[0].GetGetAccountTransactionBySequenceNumberResponse()
+func verifyTransactionByAccountSeq(
+	item *pbtypes.ResponseItem, addr types.AccountAddress,
+	sequence uint64, withEvents bool, pli *types.ProvenLedgerInfo,
+) (*types.ProvenTransaction, error) {
+	resp1 := item.GetGetAccountTransactionBySequenceNumberResponse()
 	if resp1 == nil {
 		return nil, errors.New("nil response")
 	}
 
 	if resp1.TransactionWithProof == nil {
 		state := &types.AccountStateWithProof{}
-		err = state.FromProto(resp1.ProofOfCurrentSequenceNumber)
+		err := state.FromProto(resp1.ProofOfCurrentSequenceNumber)
 		if err != nil {
 			return nil, fmt.Errorf("account state with proof from proto failed: %v", err)
 		}
@@ -118,17 +155,30 @@ func (c *Client) QueryTransactionByAccountSeq(ctx context.Context, addr types.Ac
 		if err != nil {
 			return nil, err
 		}
-		return nil, fmt.Errorf("sequence too large, should < %v", pres.SequenceNumber)
+		return nil, &TransactionNotFoundError{
+			Address:               addr,
+			SequenceNumber:        sequence,
+			AccountSequenceNumber: pres.SequenceNumber,
+			LedgerInfo:            pli,
+		}
 	}
 
 	txn := &types.TransactionWithProof{}
-	if err = txn.FromProto(resp1.TransactionWithProof); err != nil {
+	if err := txn.FromProto(resp1.TransactionWithProof); err != nil {
 		return nil, err
 	}
 
-	ptxn, err := txn.Verify(pli)
+	verify := txn.Verify
+	if withEvents {
+		verify = txn.VerifyWithEvents
+	}
+	ptxn, err := verify(pli)
 	if err != nil {
 		return nil, fmt.Errorf("transaction verify failed: %v", err)
 	}
+	stxn := ptxn.GetSignedTxn()
+	if stxn == nil || stxn.RawTxn.Sender != addr || stxn.RawTxn.SequenceNumber != sequence {
+		return nil, errors.New("transaction is not from the account at the sequence number")
+	}
 	return ptxn, nil
 }
@@ -0,0 +1,51 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/libratest"
+)
+
+func TestQueryTransactionByAccountSeq(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	s, c := libratest.StartServer(t, l)
+	libratest.Transfer(t, c, alice, bob, 0, 10)
+
+	ptxn, err := c.QueryTransactionByAccountSeq(ctx, alice.Address, 0, true)
+	require.NoError(t, err)
+	assert.True(t, ptxn.GetWithEvents())
+	assert.Len(t, ptxn.GetEvents(), 2)
+
+	ptxn, err = c.QueryTransactionByAccountSeq(ctx, alice.Address, 0, false)
+	require.NoError(t, err)
+	assert.False(t, ptxn.GetWithEvents())
+
+	for _, tc := range []struct {
+		name   string
+		tamper func(*pbtypes.TransactionWithProof)
+	}{
+		{"withheld", func(txn *pbtypes.TransactionWithProof) {
+			txn.Events = nil
+		}},
+		{"emptied", func(txn *pbtypes.TransactionWithProof) {
+			txn.Events.Events = nil
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s.TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+				tc.tamper(resp.ResponseItems[0].GetGetAccountTransactionBySequenceNumberResponse().TransactionWithProof)
+			}
+			defer func() { s.TamperResponse = nil }()
+			_, err := c.QueryTransactionByAccountSeq(ctx, alice.Address, 0, true)
+			assert.Error(t, err)
+		})
+	}
+}
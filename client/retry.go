@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// RetryPolicy is a policy to retry RPC calls on transient failures, with exponential
+// backoff and jitter.
+//
+// UpdateToLatestLedger requests, which are used by all queries, are idempotent and always
+// retried under the policy. SubmitTransaction requests are retried only if RetrySubmit is set.
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts, including the first one.
+	// Values less than 2 disable retrying.
+	MaxAttempts int
+
+	// InitialBackoff is the wait time before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait time between retries.
+	MaxBackoff time.Duration
+
+	// Multiplier is the factor by which the backoff grows after each retry.
+	Multiplier float64
+
+	// Jitter randomizes each backoff by up to this fraction, in range [0, 1].
+	Jitter float64
+
+	// RetryableCodes are the gRPC status codes which are retried.
+	RetryableCodes []codes.Code
+
+	// RetrySubmit enables retrying SubmitTransaction. A retried transaction may reach the mempool
+	// more than once, so if the mempool reports that the transaction is already there,
+	// ErrSubmissionUnconfirmed is returned. See SubmitSignedTransaction.
+	RetrySubmit bool
+}
+
+// DefaultRetryPolicy returns a retry policy with 5 max attempts, 100ms initial backoff
+// and 5s max backoff. SubmitTransaction is not retried.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableCodes: []codes.Code{
+			codes.Unavailable,
+			codes.ResourceExhausted,
+			codes.Aborted,
+			codes.DeadlineExceeded,
+		},
+	}
+}
+
+// SetRetryPolicy sets the retry policy of the client. A nil policy disables retrying,
+// which is the default.
+func (c *Client) SetRetryPolicy(p *RetryPolicy) {
+	c.epMu.Lock()
+	defer c.epMu.Unlock()
+	c.retryPolicy = p
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if e, ok := err.(*EndpointError); ok {
+		err = e.Err
+	}
+	code := errorCode(err)
+	for _, c := range p.RetryableCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// transient tells whether the error is a RPC failure which may succeed if retried later,
+// by the retryable codes of the retry policy, or the default retry policy if not set.
+func (c *Client) transient(err error) bool {
+	c.epMu.Lock()
+	p := c.retryPolicy
+	c.epMu.Unlock()
+	if p == nil {
+		p = DefaultRetryPolicy()
+	}
+	return p.retryable(err)
+}
+
+// backoff returns the wait time before the n-th retry, n starting from 1.
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < n && d < float64(p.MaxBackoff); i++ {
+		d *= p.Multiplier
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	d *= 1 - p.Jitter*rand.Float64()
+	return time.Duration(d)
+}
+
+// withRetry calls f until it succeeds, or the error is not retryable under the retry
+// policy, or the context is done. If submit is true, retrying is subject to RetrySubmit.
+// f is given the attempt number, starting from 1.
+func (c *Client) withRetry(ctx context.Context, submit bool, f func(attempt int) error) error {
+	c.epMu.Lock()
+	p := c.retryPolicy
+	c.epMu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		err := f(attempt)
+		if err == nil || p == nil || (submit && !p.RetrySubmit) ||
+			attempt >= p.MaxAttempts || !p.retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
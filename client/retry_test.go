@@ -0,0 +1,151 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+// flakyInterceptor fails the first n calls with the given code. If invoke is true, the calls
+// still reach the server, but the responses are lost.
+type flakyInterceptor struct {
+	n      int
+	code   codes.Code
+	invoke bool
+	calls  int
+}
+
+func (f *flakyInterceptor) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	f.calls++
+	if f.calls > f.n {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	if f.invoke {
+		invoker(ctx, method, req, reply, cc, opts...)
+	}
+	return status.Error(f.code, "injected failure")
+}
+
+func testRetryPolicy() *client.RetryPolicy {
+	p := client.DefaultRetryPolicy()
+	p.MaxAttempts = 3
+	p.InitialBackoff = time.Millisecond
+	return p
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	servers, addrs := libratest.StartServers(t, l)
+
+	newClient := func(f *flakyInterceptor, p *client.RetryPolicy) *client.Client {
+		c, err := client.NewWithOptions(addrs[0], l.Waypoint(),
+			client.WithUnaryInterceptors(f.intercept),
+			client.WithRetryPolicy(p),
+		)
+		require.NoError(t, err)
+		t.Cleanup(c.Close)
+		return c
+	}
+
+	t.Run("query", func(t *testing.T) {
+		c := newClient(&flakyInterceptor{n: 2, code: codes.Unavailable}, testRetryPolicy())
+		_, err := c.QueryLedgerInfo(ctx)
+		assert.NoError(t, err)
+
+		c = newClient(&flakyInterceptor{n: 3, code: codes.Unavailable}, testRetryPolicy())
+		_, err = c.QueryLedgerInfo(ctx)
+		assert.Error(t, err)
+
+		c = newClient(&flakyInterceptor{n: 1, code: codes.Unavailable}, nil)
+		_, err = c.QueryLedgerInfo(ctx)
+		assert.Error(t, err)
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		f := &flakyInterceptor{n: 1, code: codes.InvalidArgument}
+		c := newClient(f, testRetryPolicy())
+		_, err := c.QueryLedgerInfo(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, 1, f.calls)
+	})
+
+	t.Run("submit", func(t *testing.T) {
+		servers[0].HoldTransactions = true
+		defer func() { servers[0].HoldTransactions = false }()
+
+		rawTxn, err := client.NewRawP2PTransaction(alice.Address, bob.Address, nil, 0, 10, 10000, 0, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+
+		// submit is not retried by default
+		c := newClient(&flakyInterceptor{n: 1, code: codes.Unavailable, invoke: true}, testRetryPolicy())
+		_, err = c.SubmitRawTransaction(ctx, rawTxn, alice.PrivateKey)
+		assert.Error(t, err)
+
+		// the transaction is already in mempool, so a second submission fails
+		c = newClient(&flakyInterceptor{}, testRetryPolicy())
+		_, err = c.SubmitRawTransaction(ctx, rawTxn, alice.PrivateKey)
+		assert.Error(t, err)
+
+		rawTxn.SequenceNumber = 1
+		p := testRetryPolicy()
+		p.RetrySubmit = true
+		c = newClient(&flakyInterceptor{n: 1, code: codes.Unavailable, invoke: true}, p)
+		seq, err := c.SubmitRawTransaction(ctx, rawTxn, alice.PrivateKey)
+		assert.Equal(t, client.ErrSubmissionUnconfirmed, err)
+		assert.Equal(t, uint64(2), seq)
+
+		require.NoError(t, servers[0].Flush())
+		seq, err = c.QueryAccountSequenceNumber(ctx, alice.Address)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), seq)
+	})
+
+	t.Run("unconfirmed submit", func(t *testing.T) {
+		servers[0].HoldTransactions = true
+		defer func() { servers[0].HoldTransactions = false }()
+		p := testRetryPolicy()
+		p.RetrySubmit = true
+
+		// waiting confirms the transaction
+		rawTxn, err := client.NewRawP2PTransaction(alice.Address, bob.Address, nil, 2, 10, 10000, 0, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		c := newClient(&flakyInterceptor{n: 1, code: codes.Unavailable, invoke: true}, p)
+		c.SetPollPolicy(&client.PollPolicy{Interval: 10 * time.Millisecond})
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			assert.NoError(t, servers[0].Flush())
+		}()
+		ptxn, err := c.SubmitAndWait(ctx, rawTxn, alice.PrivateKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), ptxn.GetSignedTxn().RawTxn.SequenceNumber)
+
+		// the sequence manager treats it as submitted
+		f := &flakyInterceptor{code: codes.Unavailable, invoke: true}
+		c = newClient(f, p)
+		m := c.NewSequenceManager(alice.Address)
+		_, err = m.Sync(ctx)
+		require.NoError(t, err)
+		synced := f.calls
+		f.n = synced + 1
+		stxn, err := m.Submit(ctx, func(seq uint64) (*types.RawTransaction, error) {
+			return client.NewRawP2PTransaction(alice.Address, bob.Address, nil, seq, 10, 10000, 0, time.Now().Add(time.Minute))
+		}, alice.PrivateKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), stxn.RawTxn.SequenceNumber)
+		// the lost submission, and the retry
+		assert.Equal(t, 2, f.calls-synced)
+	})
+}
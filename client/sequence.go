@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/the729/go-libra/types"
+)
+
+// SequenceManager hands out sequence numbers of an account locally, so that many transactions
+// can be sent from the account concurrently, without querying the sequence number each time.
+// It is safe for concurrent use.
+//
+// Each sequence number handed out is in one of the following states, until the ledger moves past it:
+//   - reserved: handed out by Next, and not yet submitted
+//   - in flight: submitted, and waiting to be included in the ledger
+//   - free: released, or left as a gap, which will be handed out again first
+//
+// A gap is a sequence number which is not in use, but is below the highest one handed out.
+// Transactions after a gap can not be included, until the gap is filled. Gaps are left by
+// released sequence numbers, rejected or expired transactions, or transactions sent by others.
+// Gaps are detected by Sync, and are always filled first.
+type SequenceManager struct {
+	c    *Client
+	addr types.AccountAddress
+
+	mu          sync.Mutex
+	synced      bool
+	lastVersion uint64
+	next        uint64
+	reserved    map[uint64]bool
+	inflight    map[uint64]uint64
+	free        []uint64
+}
+
+// maxSubmitAttempts is the max number of attempts to submit a transaction in
+// SequenceManager.Submit, with a new sequence number each time.
+const maxSubmitAttempts = 3
+
+// NewSequenceManager creates a SequenceManager of an account. It syncs with the ledger before
+// handing out the first sequence number.
+func (c *Client) NewSequenceManager(addr types.AccountAddress) *SequenceManager {
+	return &SequenceManager{
+		c:        c,
+		addr:     addr,
+		reserved: make(map[uint64]bool),
+		inflight: make(map[uint64]uint64),
+	}
+}
+
+// Next reserves a sequence number. The caller should then either submit a transaction with it
+// and call Submitted, or call Release if it is not used.
+func (m *SequenceManager) Next(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	synced := m.synced
+	m.mu.Unlock()
+	if !synced {
+		if _, err := m.Sync(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var seq uint64
+	if len(m.free) > 0 {
+		seq, m.free = m.free[0], m.free[1:]
+	} else {
+		seq = m.next
+		m.next++
+	}
+	m.reserved[seq] = true
+	return seq, nil
+}
+
+// Release returns a reserved sequence number which is not used, so that it will be handed out again.
+func (m *SequenceManager) Release(seq uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.reserved[seq] {
+		return
+	}
+	delete(m.reserved, seq)
+	m.addFree(seq)
+}
+
+// Submitted marks a reserved sequence number as in flight, with the expiration time of the transaction.
+func (m *SequenceManager) Submitted(seq uint64, expiration time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.submitted(seq, uint64(expiration.Unix()))
+}
+
+func (m *SequenceManager) submitted(seq, expirationTime uint64) {
+	if !m.reserved[seq] {
+		return
+	}
+	delete(m.reserved, seq)
+	m.inflight[seq] = expirationTime
+}
+
+// Submit reserves a sequence number, builds a raw transaction with it, then signs and submits
+// the transaction. It returns the submitted signed transaction.
+//
+// If the transaction is rejected for its sequence number, or because the sequence number is
+// taken by another transaction in mempool, the manager syncs with the ledger, and tries again
+// with a new sequence number. So build may be called more than once. On other rejections, the
+// sequence number is released, and the error is returned. An unconfirmed retried submission,
+// i.e. ErrSubmissionUnconfirmed, is treated as submitted.
+func (m *SequenceManager) Submit(
+	ctx context.Context, build func(seq uint64) (*types.RawTransaction, error), signer types.Signer,
+) (*types.SignedTransaction, error) {
+	var lastErr error
+	for attempt := 0; attempt < maxSubmitAttempts; attempt++ {
+		seq, err := m.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rawTxn, err := build(seq)
+		if err != nil {
+			m.Release(seq)
+			return nil, err
+		}
+		if rawTxn.Sender != m.addr || rawTxn.SequenceNumber != seq {
+			m.Release(seq)
+			return nil, fmt.Errorf("built transaction is not from %x at sequence number %d", m.addr, seq)
+		}
+		signedTxn, err := rawTxn.Sign(signer)
+		if err != nil {
+			m.Release(seq)
+			return nil, fmt.Errorf("cannot sign transaction: %v", err)
+		}
+		_, err = m.c.SubmitSignedTransaction(ctx, signedTxn)
+		if err == nil || err == ErrSubmissionUnconfirmed {
+			m.mu.Lock()
+			m.submitted(seq, rawTxn.ExpirationTime)
+			m.mu.Unlock()
+			return signedTxn, nil
+		}
+		lastErr = err
+
+		switch e := err.(type) {
+		case *VMStatusError:
+			m.Release(seq)
+			if e.Status != types.SEQUENCE_NUMBER_TOO_OLD && e.Status != types.SEQUENCE_NUMBER_TOO_NEW {
+				return nil, err
+			}
+		case *MempoolError:
+			switch e.Code {
+			case types.MempoolInvalidUpdate:
+				// The sequence number may be occupied by another transaction in mempool.
+				// If that transaction expires, the gap will be detected by Sync.
+				m.mu.Lock()
+				m.submitted(seq, rawTxn.ExpirationTime)
+				m.mu.Unlock()
+			case types.MempoolInvalidSeqNumber:
+				m.Release(seq)
+			default:
+				// The transaction never entered mempool, and another sequence number won't help.
+				m.Release(seq)
+				return nil, err
+			}
+		default:
+			// The transaction may or may not have reached the mempool.
+			m.mu.Lock()
+			m.submitted(seq, rawTxn.ExpirationTime)
+			m.mu.Unlock()
+			return nil, err
+		}
+		if _, err := m.Sync(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// Sync updates the manager with the proven sequence number of the account. Sequence numbers
+// below it are forgotten. Gaps above it are detected, which are returned in ascending order,
+// and will be handed out first.
+//
+// In-flight transactions which have expired by the ledger timestamp are gaps. So Sync should
+// be called from time to time, e.g. every minute, for gaps left by expired transactions to
+// be filled.
+func (m *SequenceManager) Sync(ctx context.Context) ([]uint64, error) {
+	paccount, err := m.c.QueryAccountState(ctx, m.addr)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := accountSequenceNumber(paccount)
+	if err != nil {
+		return nil, err
+	}
+	ledgerInfo := paccount.GetLedgerInfo()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.synced && ledgerInfo.GetVersion() < m.lastVersion {
+		return nil, nil
+	}
+	m.synced = true
+	m.lastVersion = ledgerInfo.GetVersion()
+
+	free := m.free[:0]
+	for _, s := range m.free {
+		if s >= seq {
+			free = append(free, s)
+		}
+	}
+	m.free = free
+	for s := range m.reserved {
+		if s < seq {
+			delete(m.reserved, s)
+		}
+	}
+	for s, expirationTime := range m.inflight {
+		expired := expirationTime <= math.MaxUint64/1000000 && ledgerInfo.GetTimestampUsec() >= expirationTime*1000000
+		if s < seq || expired {
+			delete(m.inflight, s)
+		}
+	}
+	if m.next < seq {
+		m.next = seq
+	}
+
+	var gaps []uint64
+	for s := seq; s < m.next; s++ {
+		if _, ok := m.inflight[s]; !ok && !m.reserved[s] && !m.isFree(s) {
+			gaps = append(gaps, s)
+		}
+	}
+	for _, s := range gaps {
+		m.addFree(s)
+	}
+	return gaps, nil
+}
+
+func (m *SequenceManager) isFree(seq uint64) bool {
+	i := sort.Search(len(m.free), func(i int) bool { return m.free[i] >= seq })
+	return i < len(m.free) && m.free[i] == seq
+}
+
+// addFree inserts a sequence number into the sorted free list.
+func (m *SequenceManager) addFree(seq uint64) {
+	i := sort.Search(len(m.free), func(i int) bool { return m.free[i] >= seq })
+	if i < len(m.free) && m.free[i] == seq {
+		return
+	}
+	m.free = append(m.free, 0)
+	copy(m.free[i+1:], m.free[i:])
+	m.free[i] = seq
+}
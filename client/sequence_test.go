@@ -0,0 +1,115 @@
+package client_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestSequenceManager(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000000)
+	bob := libratest.NewAccount(t, l, 0)
+	s, c := libratest.StartServer(t, l)
+	m := c.NewSequenceManager(alice.Address)
+
+	expiration := time.Now().Add(time.Minute)
+	build := func(seq uint64) (*types.RawTransaction, error) {
+		return client.NewRawP2PTransaction(alice.Address, bob.Address, nil, seq, 10, 10000, 0, expiration)
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		seqs := make(map[uint64]bool)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				stxn, err := m.Submit(ctx, build, alice.PrivateKey)
+				if !assert.NoError(t, err) {
+					return
+				}
+				mu.Lock()
+				seqs[stxn.RawTxn.SequenceNumber] = true
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		assert.Len(t, seqs, 20)
+		seq, err := c.QueryAccountSequenceNumber(ctx, alice.Address)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(20), seq)
+	})
+
+	t.Run("sequence number too old", func(t *testing.T) {
+		// another sender uses the next sequence number
+		libratest.Transfer(t, c, alice, bob, 20, 10)
+		stxn, err := m.Submit(ctx, build, alice.PrivateKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(21), stxn.RawTxn.SequenceNumber)
+	})
+
+	t.Run("mempool is full", func(t *testing.T) {
+		s.RejectTransactions = types.MempoolIsFull
+		defer func() { s.RejectTransactions = types.MempoolAccepted }()
+		_, err := m.Submit(ctx, build, alice.PrivateKey)
+		require.IsType(t, &client.MempoolError{}, err)
+		assert.Equal(t, types.MempoolIsFull, err.(*client.MempoolError).Code)
+
+		// the sequence number is not in flight
+		seq, err := m.Next(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(22), seq)
+		m.Release(seq)
+	})
+
+	t.Run("gaps", func(t *testing.T) {
+		s.HoldTransactions = true
+		defer func() { s.HoldTransactions = false }()
+
+		// 22 expires, so 23 is stuck in mempool
+		expiration = time.Now().Add(time.Minute)
+		stxn, err := m.Submit(ctx, build, alice.PrivateKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(22), stxn.RawTxn.SequenceNumber)
+		expiration = time.Now().Add(2 * time.Hour)
+		stxn, err = m.Submit(ctx, build, alice.PrivateKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(23), stxn.RawTxn.SequenceNumber)
+
+		l.Clock = func() time.Time { return time.Now().Add(time.Hour) }
+		libratest.CommitBlock(t, l, 100)
+		require.NoError(t, s.Flush())
+		gaps, err := m.Sync(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{22}, gaps)
+
+		// the gap is filled first
+		s.HoldTransactions = false
+		stxn, err = m.Submit(ctx, build, alice.PrivateKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(22), stxn.RawTxn.SequenceNumber)
+		seq, err := c.QueryAccountSequenceNumber(ctx, alice.Address)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(24), seq)
+
+		// released sequence numbers are handed out again
+		seq, err = m.Next(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(24), seq)
+		m.Release(seq)
+		stxn, err = m.Submit(ctx, build, alice.PrivateKey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(24), stxn.RawTxn.SequenceNumber)
+	})
+}
@@ -103,10 +103,15 @@ func (c *Client) SetState(cs *State) error {
 	}
 
 	c.accMu.Lock()
-	defer c.accMu.Unlock()
 	c.acc = acc
 	c.verifier = verifier
 	c.lastWaypoint = cs.Waypoint
+	c.accMu.Unlock()
+
+	// cached objects may be proven by ledger infos inconsistent with the new state
+	if cache := c.getCache(); cache != nil {
+		cache.Purge()
+	}
 	return nil
 }
 
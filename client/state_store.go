@@ -0,0 +1,285 @@
+package client
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/the729/go-libra/internal/fileutil"
+)
+
+// DefaultStateHistory is the default number of states kept by a StateStore.
+const DefaultStateHistory = 10
+
+// StateStore persists the trusted states of a client. Once a state store is set, the client
+// saves its state after every verified ledger info which advances the known version or the
+// epoch, so that a crash never loses the trusted accumulator and validator set.
+//
+// A state store keeps a number of the latest states, so that the client can be rolled back
+// to an earlier state with SetState.
+type StateStore interface {
+	// SaveState saves the state as the latest one, and drops the oldest states beyond the
+	// history limit. The save should be atomic.
+	SaveState(state *State) error
+
+	// LoadStates loads the kept states, the latest first. It returns an empty list if
+	// no state is saved yet.
+	LoadStates() ([]*State, error)
+}
+
+// LoadLatestState loads the latest state from the store. If no state is saved yet,
+// it returns nil.
+func LoadLatestState(s StateStore) (*State, error) {
+	states, err := s.LoadStates()
+	if err != nil || len(states) == 0 {
+		return nil, err
+	}
+	return states[0], nil
+}
+
+func pushState(states []*State, state *State, history int) []*State {
+	if history <= 0 {
+		history = DefaultStateHistory
+	}
+	states = append([]*State{state}, states...)
+	if len(states) > history {
+		states = states[:history]
+	}
+	return states
+}
+
+// MemoryStateStore is a StateStore in memory.
+type MemoryStateStore struct {
+	mu      sync.Mutex
+	history int
+	states  []*State
+}
+
+// NewMemoryStateStore creates an empty MemoryStateStore, which keeps at most history
+// states. A non-positive history means DefaultStateHistory.
+func NewMemoryStateStore(history int) *MemoryStateStore {
+	return &MemoryStateStore{history: history}
+}
+
+// SaveState implements StateStore.
+func (s *MemoryStateStore) SaveState(state *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states = pushState(s.states, state, s.history)
+	return nil
+}
+
+// LoadStates implements StateStore.
+func (s *MemoryStateStore) LoadStates() ([]*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*State(nil), s.states...), nil
+}
+
+// FileStateStore is a StateStore backed by a JSON file. The file is replaced atomically on
+// each save, so that a crash never leaves a partially written file.
+type FileStateStore struct {
+	mu      sync.Mutex
+	path    string
+	history int
+}
+
+// NewFileStateStore creates a FileStateStore with the file path, which keeps at most
+// history states. A non-positive history means DefaultStateHistory. The file is created on
+// the first save if it does not exist.
+func NewFileStateStore(path string, history int) *FileStateStore {
+	return &FileStateStore{path: path, history: history}
+}
+
+func (s *FileStateStore) load() ([]*State, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var states []*State
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("state file %s corrupted: %v", s.path, err)
+	}
+	return states, nil
+}
+
+// SaveState implements StateStore.
+func (s *FileStateStore) SaveState(state *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states, err := s.load()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(pushState(states, state, s.history), "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(s.path, data)
+}
+
+// LoadStates implements StateStore.
+func (s *FileStateStore) LoadStates() ([]*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// KV is a minimal interface of an embedded key-value database, e.g. BoltDB, LevelDB or
+// Badger, to be used by KVStateStore. Get returns nil if the key is not found.
+type KV interface {
+	Get(key []byte) ([]byte, error)
+	Put(key, value []byte) error
+	Delete(key []byte) error
+}
+
+// KVStateStore is a StateStore backed by an embedded key-value database.
+//
+// Each state is put under its own key, and then an index of the kept keys is put. Thus the
+// saved states are always consistent, even if the database does not support transactions.
+type KVStateStore struct {
+	mu      sync.Mutex
+	kv      KV
+	prefix  string
+	history int
+}
+
+// NewKVStateStore creates a KVStateStore, which keeps at most history states in the
+// database. A non-positive history means DefaultStateHistory. All keys start with prefix,
+// so that the database can be shared with other data.
+func NewKVStateStore(kv KV, prefix string, history int) *KVStateStore {
+	return &KVStateStore{kv: kv, prefix: prefix, history: history}
+}
+
+func (s *KVStateStore) indexKey() []byte {
+	return []byte(s.prefix + "index")
+}
+
+func (s *KVStateStore) stateKey(id uint64) []byte {
+	key := append([]byte(s.prefix+"state/"), 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(key[len(key)-8:], id)
+	return key
+}
+
+// loadIndex loads the ids of kept states, the latest first.
+func (s *KVStateStore) loadIndex() ([]uint64, error) {
+	data, err := s.kv.Get(s.indexKey())
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+	var ids []uint64
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("state index corrupted: %v", err)
+	}
+	return ids, nil
+}
+
+// SaveState implements StateStore.
+func (s *KVStateStore) SaveState(state *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids, err := s.loadIndex()
+	if err != nil {
+		return err
+	}
+	id := uint64(0)
+	if len(ids) > 0 {
+		id = ids[0] + 1
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := s.kv.Put(s.stateKey(id), data); err != nil {
+		return err
+	}
+
+	history := s.history
+	if history <= 0 {
+		history = DefaultStateHistory
+	}
+	ids = append([]uint64{id}, ids...)
+	var dropped []uint64
+	if len(ids) > history {
+		ids, dropped = ids[:history], ids[history:]
+	}
+	index, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	if err := s.kv.Put(s.indexKey(), index); err != nil {
+		return err
+	}
+	for _, id := range dropped {
+		if err := s.kv.Delete(s.stateKey(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadStates implements StateStore.
+func (s *KVStateStore) LoadStates() ([]*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	states := make([]*State, 0, len(ids))
+	for _, id := range ids {
+		data, err := s.kv.Get(s.stateKey(id))
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			return nil, fmt.Errorf("state %d not found", id)
+		}
+		state := &State{}
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("state %d corrupted: %v", id, err)
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// SetStateStore sets the store to which the trusted state is saved after every verified
+// ledger info which advances the known version or the epoch. A nil store disables saving.
+//
+// The current state is not saved until it advances. To restore a client from the store, use
+// LoadLatestState and SetState, or WithStateStore.
+func (c *Client) SetStateStore(s StateStore) {
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+	c.stateStore = s
+	c.lastSavedState = nil
+}
+
+// saveState saves the current state to the state store, if any.
+func (c *Client) saveState() error {
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+	if c.stateStore == nil {
+		return nil
+	}
+	state := c.GetState()
+	if last := c.lastSavedState; last != nil && last.KnownVersion == state.KnownVersion && last.Waypoint == state.Waypoint {
+		// already saved by a concurrent update
+		return nil
+	}
+	if err := c.stateStore.SaveState(state); err != nil {
+		return fmt.Errorf("save trusted state error: %v", err)
+	}
+	c.lastSavedState = state
+	return nil
+}
@@ -0,0 +1,127 @@
+package client_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/libratest"
+)
+
+type mapKV struct {
+	mu sync.Mutex
+	m  map[string][]byte
+}
+
+func (kv *mapKV) Get(key []byte) ([]byte, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.m[string(key)], nil
+}
+
+func (kv *mapKV) Put(key, value []byte) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.m[string(key)] = append([]byte(nil), value...)
+	return nil
+}
+
+func (kv *mapKV) Delete(key []byte) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	delete(kv.m, string(key))
+	return nil
+}
+
+// assertSameState asserts that two states are equal, regardless of the order of validators.
+func assertSameState(t *testing.T, expected, actual *client.State) {
+	assert.ElementsMatch(t, expected.ValidatorSet, actual.ValidatorSet)
+	e, a := *expected, *actual
+	e.ValidatorSet, a.ValidatorSet = nil, nil
+	assert.Equal(t, e, a)
+}
+
+func TestStateStore(t *testing.T) {
+	ctx := context.Background()
+	kv := &mapKV{m: make(map[string][]byte)}
+	stores := map[string]func(t *testing.T) client.StateStore{
+		"memory": func(t *testing.T) client.StateStore { return client.NewMemoryStateStore(3) },
+		"file": func(t *testing.T) client.StateStore {
+			return client.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), 3)
+		},
+		"kv": func(t *testing.T) client.StateStore { return client.NewKVStateStore(kv, "libra/", 3) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			l := libratest.NewLedger(4)
+			_, c := libratest.StartServer(t, l)
+			store := newStore(t)
+			c.SetStateStore(store)
+
+			state, err := client.LoadLatestState(store)
+			require.NoError(t, err)
+			assert.Nil(t, state)
+
+			// genesis epoch change
+			_, err = c.QueryLedgerInfo(ctx)
+			require.NoError(t, err)
+			// nothing advances
+			_, err = c.QueryLedgerInfo(ctx)
+			require.NoError(t, err)
+			states, err := store.LoadStates()
+			require.NoError(t, err)
+			require.Len(t, states, 1)
+			assertSameState(t, c.GetState(), states[0])
+
+			for i := uint64(1); i <= 3; i++ {
+				libratest.CommitBlock(t, l, i)
+				_, err = c.QueryLedgerInfo(ctx)
+				require.NoError(t, err)
+			}
+			require.NoError(t, l.Reconfigure(libratest.GenerateValidators(5)))
+			_, err = c.QueryLedgerInfo(ctx)
+			require.NoError(t, err)
+
+			states, err = store.LoadStates()
+			require.NoError(t, err)
+			require.Len(t, states, 3)
+			assertSameState(t, c.GetState(), states[0])
+			assert.Equal(t, l.Version(), states[0].KnownVersion)
+			assert.Equal(t, l.Epoch(), states[0].Epoch)
+			assert.True(t, states[1].KnownVersion < states[0].KnownVersion)
+			assert.True(t, states[2].KnownVersion < states[1].KnownVersion)
+
+			// roll back, and catch up again
+			require.NoError(t, c.SetState(states[2]))
+			_, err = c.QueryLedgerInfo(ctx)
+			require.NoError(t, err)
+			assertSameState(t, states[0], c.GetState())
+		})
+	}
+	assert.Len(t, kv.m, 4)
+}
+
+func TestWithStateStore(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	_, addrs := libratest.StartServers(t, l)
+	store := client.NewMemoryStateStore(0)
+
+	c, err := client.NewWithOptions(addrs[0], l.Waypoint(), client.WithStateStore(store))
+	require.NoError(t, err)
+	libratest.CommitBlock(t, l, 1)
+	_, err = c.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+	c.Close()
+
+	// restored without a waypoint
+	c, err = client.NewWithOptions(addrs[0], "", client.WithStateStore(store))
+	require.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, l.Version(), c.GetState().KnownVersion)
+}
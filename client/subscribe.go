@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/the729/go-libra/types"
+)
+
+// SubscribeLedger polls the ledger info every interval, and sends newly verified ledger
+// infos to the returned channel. A ledger info is sent only if its version is higher than
+// the previously sent one.
+//
+// When the client switches to the validator set of a new epoch, the last ledger info of each
+// previous epoch since the last poll is sent before the latest ledger info, as an epoch change
+// notification. Such a ledger info can be told by a non-nil GetNextValidatorSet(). If the
+// client has not verified any epoch change when subscribing, only the latest epoch change is
+// sent at the first poll.
+//
+// Failed queries are ignored, and retried at the next tick. The channel is closed after the
+// context is done. The channel is not buffered, so polling pauses until the receiver catches up.
+func (c *Client) SubscribeLedger(ctx context.Context, interval time.Duration) <-chan *types.ProvenLedgerInfo {
+	ch := make(chan *types.ProvenLedgerInfo)
+
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		c.accMu.RLock()
+		lastEpochChange := c.lastEpochChange
+		c.accMu.RUnlock()
+		var last *types.ProvenLedgerInfo
+
+		send := func(pli *types.ProvenLedgerInfo) bool {
+			if last != nil && pli.GetVersion() <= last.GetVersion() {
+				return true
+			}
+			select {
+			case ch <- pli:
+				last = pli
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			if pli, err := c.QueryLedgerInfo(ctx); err == nil {
+				c.accMu.RLock()
+				epochChange := c.lastEpochChange
+				c.accMu.RUnlock()
+				if epochChange != lastEpochChange {
+					epochChanges := []*types.ProvenLedgerInfo{epochChange}
+					if lastEpochChange != nil && epochChange.GetEpochNum() > lastEpochChange.GetEpochNum()+1 {
+						// the client skipped intermediate epoch changes, which are queried again
+						epochChanges, err = c.queryEpochChanges(ctx, lastEpochChange, epochChange.GetEpochNum())
+					}
+					if err == nil {
+						lastEpochChange = epochChange
+						for _, ec := range epochChanges {
+							if !send(ec) {
+								return
+							}
+						}
+					}
+				}
+				if err == nil && !send(pli) {
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,91 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func recvLedgerInfo(t *testing.T, ch <-chan *types.ProvenLedgerInfo) *types.ProvenLedgerInfo {
+	select {
+	case pli, ok := <-ch:
+		require.True(t, ok)
+		return pli
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout")
+	}
+	return nil
+}
+
+func TestSubscribeLedger(t *testing.T) {
+	l := libratest.NewLedger(4)
+	_, c := libratest.StartServer(t, l)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := c.SubscribeLedger(ctx, 10*time.Millisecond)
+
+	recv := func() *types.ProvenLedgerInfo { return recvLedgerInfo(t, ch) }
+
+	// the genesis ledger info ends epoch 0
+	pli := recv()
+	assert.Equal(t, uint64(0), pli.GetVersion())
+	assert.NotNil(t, pli.GetNextValidatorSet())
+	pli = recv()
+	assert.Equal(t, l.Version(), pli.GetVersion())
+	assert.Nil(t, pli.GetNextValidatorSet())
+
+	libratest.CommitBlock(t, l, 100)
+	pli = recv()
+	assert.Equal(t, l.Version(), pli.GetVersion())
+
+	epoch := l.Epoch()
+	require.NoError(t, l.Reconfigure(nil))
+	pli = recv()
+	assert.Equal(t, epoch, pli.GetEpochNum())
+	assert.NotNil(t, pli.GetNextValidatorSet())
+	pli = recv()
+	assert.Equal(t, epoch+1, pli.GetEpochNum())
+	assert.Equal(t, l.Version(), pli.GetVersion())
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestSubscribeLedgerEpochChanges(t *testing.T) {
+	l := libratest.NewLedger(4)
+	s, c := libratest.StartServer(t, l)
+	s.MaxEpochChanges = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, l.Reconfigure(nil))
+	_, err := c.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+	epoch := l.Epoch()
+
+	// several epochs change before the first poll
+	for i := 0; i < 4; i++ {
+		require.NoError(t, l.Reconfigure(nil))
+	}
+	ch := c.SubscribeLedger(ctx, 10*time.Millisecond)
+	for e := epoch; e < l.Epoch(); e++ {
+		pli := recvLedgerInfo(t, ch)
+		assert.Equal(t, e, pli.GetEpochNum())
+		assert.NotNil(t, pli.GetNextValidatorSet())
+	}
+	pli := recvLedgerInfo(t, ch)
+	assert.Equal(t, l.Epoch(), pli.GetEpochNum())
+	assert.Equal(t, l.Version(), pli.GetVersion())
+
+	cancel()
+	for range ch {
+	}
+}
@@ -1,26 +1,34 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
-	"golang.org/x/crypto/ed25519"
-
 	"github.com/the729/go-libra/generated/pbac"
 	"github.com/the729/go-libra/language/stdscript"
 	"github.com/the729/go-libra/types"
+	"github.com/the729/lcs"
 )
 
 // NewRawP2PTransaction creates a new serialized raw transaction bytes corresponding to a
 // peer-to-peer Libra coin transaction.
+//
+// The receiverAuthKeyPrefix is the Prefix() of the authentication key of the receiver, whose
+// DerivedAddress() is receiverAddress. It is needed if the receiver account does not exist yet,
+// and may be empty otherwise.
 func NewRawP2PTransaction(
 	senderAddress, receiverAddress types.AccountAddress, receiverAuthKeyPrefix []byte,
 	senderSequenceNumber uint64,
 	amount, maxGasAmount, gasUnitPrice uint64,
 	expiration time.Time,
 ) (*types.RawTransaction, error) {
+	if len(receiverAuthKeyPrefix) != 0 && len(receiverAuthKeyPrefix) != types.AuthenticationKeyPrefixLength {
+		return nil, fmt.Errorf("wrong receiver auth key prefix length %d", len(receiverAuthKeyPrefix))
+	}
 	txn := &types.RawTransaction{
 		Sender:         senderAddress,
 		SequenceNumber: senderSequenceNumber,
@@ -42,16 +50,76 @@ func NewRawP2PTransaction(
 	return txn, nil
 }
 
+// VMStatusError is returned when a submitted transaction fails the validation by VM.
+type VMStatusError struct {
+	Status  types.VMStatusCode
+	Message string
+}
+
+func (e *VMStatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("vm error: %s", e.Status)
+	}
+	return fmt.Sprintf("vm error: %s: %s", e.Status, e.Message)
+}
+
+// MempoolError is returned when a submitted transaction is rejected by mempool.
+type MempoolError struct {
+	Code    types.MempoolStatusCode
+	Message string
+}
+
+func (e *MempoolError) Error() string {
+	return fmt.Sprintf("mempool error: code %d: %s", e.Code, e.Message)
+}
+
+// ErrSubmissionUnconfirmed is returned along with the expected sequence number, when a
+// submission is retried, and mempool reports that a transaction of the sender at the sequence
+// number is already there. See SubmitSignedTransaction.
+var ErrSubmissionUnconfirmed = errors.New("submission unconfirmed: a transaction at the sequence number is already in mempool")
+
 // SubmitRawTransaction signes and submits a raw transaction.
 // It returns the expected sequence number of this transaction.
-func (c *Client) SubmitRawTransaction(ctx context.Context, rawTxn *types.RawTransaction, privateKey ed25519.PrivateKey) (uint64, error) {
-	signedTxn, err := rawTxn.Sign(privateKey)
+//
+// The signer can be an ed25519.PrivateKey, or any other types.Signer, e.g. a remote signer.
+//
+// If the transaction is rejected, a *VMStatusError or *MempoolError is returned.
+func (c *Client) SubmitRawTransaction(ctx context.Context, rawTxn *types.RawTransaction, signer types.Signer) (uint64, error) {
+	signedTxn, err := rawTxn.Sign(signer)
 	if err != nil {
 		return 0, fmt.Errorf("cannot sign transaction: %v", err)
 	}
-	pbSignedTxn, _ := signedTxn.ToProto()
-	resp, err := c.ac.SubmitTransaction(ctx, &pbac.SubmitTransactionRequest{
-		Transaction: pbSignedTxn,
+	return c.SubmitSignedTransaction(ctx, signedTxn)
+}
+
+// SubmitSignedTransaction submits a signed transaction, e.g. one aggregated from
+// partial signatures of a multi-signature account.
+// It returns the expected sequence number of this transaction.
+//
+// If the transaction is rejected, a *VMStatusError or *MempoolError is returned.
+//
+// If the submission is retried under the retry policy, and mempool reports that a transaction
+// of the sender at the sequence number is already there, it returns the expected sequence
+// number with ErrSubmissionUnconfirmed. The transaction in mempool may be the submitted one,
+// whose earlier response was lost, or another one, e.g. one sent by another client of the same
+// account. Wait for the transaction to confirm it, e.g. with SubmitAndWait, which checks that
+// the included transaction is exactly the submitted one.
+func (c *Client) SubmitSignedTransaction(ctx context.Context, signedTxn *types.SignedTransaction) (uint64, error) {
+	rawTxn := signedTxn.RawTxn
+	pbSignedTxn, err := signedTxn.ToProto()
+	if err != nil {
+		return 0, fmt.Errorf("cannot serialize transaction: %v", err)
+	}
+	var resp *pbac.SubmitTransactionResponse
+	attempts := 0
+	err = c.withRetry(ctx, true, func(attempt int) error {
+		attempts = attempt
+		return c.withFailover(ctx, func(ep *endpoint) error {
+			resp, err = ep.ac.SubmitTransaction(ctx, &pbac.SubmitTransactionRequest{
+				Transaction: pbSignedTxn,
+			})
+			return err
+		})
 	})
 	if err != nil {
 		return 0, fmt.Errorf("submit transaction error: %v", err)
@@ -60,43 +128,126 @@ func (c *Client) SubmitRawTransaction(ctx context.Context, rawTxn *types.RawTran
 	// log.Printf("Result: ")
 	// spew.Dump(resp)
 	if vmStatus := resp.GetVmStatus(); vmStatus != nil {
-		return 0, fmt.Errorf("vm error: %s", vmStatus)
+		return 0, &VMStatusError{
+			Status:  types.VMStatusCode(vmStatus.MajorStatus),
+			Message: vmStatus.Message,
+		}
 	}
 	if mpStatus := resp.GetMempoolStatus(); mpStatus != nil {
-		return 0, fmt.Errorf("mempool error: %s", mpStatus)
+		// A previous attempt may have reached the mempool, though its response was lost.
+		// It can not be told from another transaction at the same sequence number, until included.
+		if attempts > 1 && types.MempoolStatusCode(mpStatus.Code) == types.MempoolInvalidUpdate {
+			return rawTxn.SequenceNumber + 1, ErrSubmissionUnconfirmed
+		}
+		return 0, &MempoolError{
+			Code:    types.MempoolStatusCode(mpStatus.Code),
+			Message: mpStatus.Message,
+		}
 	}
-	if acStatus := resp.GetAcStatus(); acStatus.Code != pbac.AdmissionControlStatusCode_Accepted {
+	if acStatus := resp.GetAcStatus(); acStatus == nil || acStatus.Code != pbac.AdmissionControlStatusCode_Accepted {
 		return 0, fmt.Errorf("ac error: %s", acStatus)
 	}
 
 	return rawTxn.SequenceNumber + 1, nil
 }
 
-// PollSequenceUntil blocks to repeatedly poll the sequence number of a specific account, until the sequence number
-// is greater or equal to specified target sequence number, or the ledger state passes specified expiration time.
-func (c *Client) PollSequenceUntil(ctx context.Context, addr types.AccountAddress, targetSeq uint64, expiration time.Time) error {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-	for range ticker.C {
-		paccount, err := c.QueryAccountState(ctx, addr)
-		if err != nil {
-			return err
-		}
-		ledgerInfo := paccount.GetLedgerInfo()
-		if !paccount.IsNil() {
-			resource, err := paccount.GetAccountBlob().GetLibraAccountResource()
+// TransactionExpiredError is returned when a transaction is proven not to be included in the
+// ledger, while the ledger timestamp has passed the expiration time of the transaction. Such
+// a transaction will never be included.
+type TransactionExpiredError struct {
+	ExpirationTime time.Time
+	LedgerInfo     *types.ProvenLedgerInfo
+}
+
+func (e *TransactionExpiredError) Error() string {
+	ledgerTime := time.Unix(0, int64(e.LedgerInfo.GetTimestampUsec())*1000)
+	return fmt.Sprintf("transaction expired at %s, ledger time is %s", e.ExpirationTime.UTC(), ledgerTime.UTC())
+}
+
+// SubmitAndWait signs and submits a raw transaction, and waits until it is included in the ledger.
+//
+// It returns the proven transaction with events, which has exactly the same content and signature
+// as submitted. The caller should check GetMajorStatus() of the transaction, because a transaction
+// may be included in the ledger though failed to execute.
+//
+// If the ledger timestamp passes the expiration time before the transaction is included,
+// a *TransactionExpiredError is returned. Transient RPC failures while waiting, e.g.
+// Unavailable, are retried at the poll interval, until the context is done.
+func (c *Client) SubmitAndWait(ctx context.Context, rawTxn *types.RawTransaction, signer types.Signer) (*types.ProvenTransaction, error) {
+	signedTxn, err := rawTxn.Sign(signer)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign transaction: %v", err)
+	}
+	// an unconfirmed submission is confirmed or refuted by waiting
+	if _, err := c.SubmitSignedTransaction(ctx, signedTxn); err != nil && err != ErrSubmissionUnconfirmed {
+		return nil, err
+	}
+	return c.waitTransaction(ctx, signedTxn)
+}
+
+// waitTransaction polls the transaction from the sender at the sequence number of the signed
+// transaction, until it is included in the ledger, or the transaction expires. Transient RPC
+// failures are retried under the poll policy, until the context is done.
+func (c *Client) waitTransaction(ctx context.Context, signedTxn *types.SignedTransaction) (*types.ProvenTransaction, error) {
+	expected, err := lcs.Marshal(signedTxn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize transaction: %v", err)
+	}
+	rawTxn := signedTxn.RawTxn
+	p := c.newPoller()
+	for {
+		ptxn, err := c.QueryTransactionByAccountSeq(ctx, rawTxn.Sender, rawTxn.SequenceNumber, true)
+		if err == nil {
+			got, err := lcs.Marshal(ptxn.GetSignedTxn())
 			if err != nil {
-				return err
+				return nil, fmt.Errorf("cannot serialize transaction: %v", err)
+			}
+			if !bytes.Equal(got, expected) {
+				return nil, fmt.Errorf("another transaction is included at sequence number %d", rawTxn.SequenceNumber)
+			}
+			return ptxn, nil
+		}
+		nf, ok := err.(*TransactionNotFoundError)
+		if !ok {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if !c.transient(err) {
+				return nil, err
 			}
-			seq := resource.SequenceNumber
-			// log.Printf("sequence number: %d, ledger version: %d", seq, ledgerInfo.GetVersion())
-			if seq >= targetSeq {
-				return nil
+			if err := p.wait(ctx, p.lastVersion); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if rawTxn.ExpirationTime <= math.MaxUint64/1000000 &&
+			nf.LedgerInfo.GetTimestampUsec() >= rawTxn.ExpirationTime*1000000 {
+			return nil, &TransactionExpiredError{
+				ExpirationTime: time.Unix(int64(rawTxn.ExpirationTime), 0),
+				LedgerInfo:     nf.LedgerInfo,
 			}
 		}
-		if ledgerInfo.GetTimestampUsec() > uint64(expiration.Unix()+1)*1000000 {
-			break
+		if err := p.wait(ctx, nf.LedgerInfo.GetVersion()); err != nil {
+			return nil, err
 		}
 	}
-	return errors.New("expired")
+}
+
+// PollSequenceUntil blocks to repeatedly poll the sequence number of a specific account, until the sequence number
+// is greater or equal to specified target sequence number, or the ledger state passes specified expiration time.
+//
+// The poll interval follows the poll policy of the client, and transient RPC failures are retried. On failure,
+// a *WaitError is returned, which reports the last proven sequence number and ledger version.
+func (c *Client) PollSequenceUntil(ctx context.Context, addr types.AccountAddress, targetSeq uint64, expiration time.Time) error {
+	_, err := c.WaitAccountState(ctx, addr, expiration, func(paccount *types.ProvenAccountState) (bool, error) {
+		if paccount.IsNil() {
+			return false, nil
+		}
+		seq, err := accountSequenceNumber(paccount)
+		if err != nil {
+			return false, err
+		}
+		return seq >= targetSeq, nil
+	})
+	return err
 }
@@ -0,0 +1,85 @@
+package client_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestSubmitAndWait(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	s, c := libratest.StartServer(t, l)
+	c.SetPollPolicy(&client.PollPolicy{Interval: 10 * time.Millisecond})
+
+	t.Run("executed", func(t *testing.T) {
+		rawTxn, err := client.NewRawP2PTransaction(alice.Address, bob.Address, nil, 0, 10, 10000, 0, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		ptxn, err := c.SubmitAndWait(ctx, rawTxn, alice.PrivateKey)
+		require.NoError(t, err)
+		assert.Equal(t, types.EXECUTED, ptxn.GetMajorStatus())
+		assert.Equal(t, rawTxn.SequenceNumber, ptxn.GetSignedTxn().RawTxn.SequenceNumber)
+		assert.True(t, ptxn.GetWithEvents())
+		assert.Len(t, ptxn.GetEvents(), 2)
+	})
+
+	t.Run("transient failures", func(t *testing.T) {
+		// the first polls fail after the transaction is submitted
+		var submitted bool
+		failures := 2
+		flaky := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			if strings.HasSuffix(method, "/SubmitTransaction") {
+				submitted = true
+			} else if submitted && failures > 0 {
+				failures--
+				return status.Error(codes.Unavailable, "injected failure")
+			}
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		_, addrs := libratest.StartServers(t, l)
+		c, err := client.NewWithOptions(addrs[0], l.Waypoint(), client.WithUnaryInterceptors(flaky))
+		require.NoError(t, err)
+		defer c.Close()
+		c.SetPollPolicy(&client.PollPolicy{Interval: 10 * time.Millisecond})
+
+		rawTxn, err := client.NewRawP2PTransaction(alice.Address, bob.Address, nil, 1, 10, 10000, 0, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		ptxn, err := c.SubmitAndWait(ctx, rawTxn, alice.PrivateKey)
+		require.NoError(t, err)
+		assert.Equal(t, types.EXECUTED, ptxn.GetMajorStatus())
+		assert.Equal(t, 0, failures)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s.HoldTransactions = true
+		rawTxn, err := client.NewRawP2PTransaction(alice.Address, bob.Address, nil, 2, 10, 10000, 0, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			l.Clock = func() time.Time { return time.Now().Add(time.Hour) }
+			assert.NoError(t, l.Commit(&libratest.TransactionToCommit{
+				Transaction: &types.Transaction{Transaction: &types.BlockMetaData{Round: 100}},
+				MajorStatus: types.EXECUTED,
+			}))
+		}()
+		_, err = c.SubmitAndWait(ctx, rawTxn, alice.PrivateKey)
+		require.Error(t, err)
+		eerr, ok := err.(*client.TransactionExpiredError)
+		require.True(t, ok, err.Error())
+		assert.Equal(t, rawTxn.ExpirationTime, uint64(eerr.ExpirationTime.Unix()))
+	})
+}
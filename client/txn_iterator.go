@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/the729/go-libra/types"
+)
+
+// Default settings of TransactionIterator.
+const (
+	DefaultTransactionChunkSize    = 100
+	DefaultTransactionPrefetch     = 2
+	DefaultTransactionPollInterval = time.Second
+)
+
+// FollowTip can be used as the end version of a TransactionIterator, so that it follows
+// the tip of the ledger without end.
+const FollowTip = ^uint64(0)
+
+// TransactionIterator walks through a range of transactions, fetching them chunk by chunk
+// in the background.
+//
+// Each chunk is verified with its accumulator range proof, and is checked to contain all
+// available transactions in the requested range. If the end of the range has not been
+// committed yet, the iterator waits for the ledger to grow.
+//
+// Usage:
+//
+//	it := c.NewTransactionIterator(ctx, from, to, false)
+//	defer it.Close()
+//	for it.Next() {
+//	    txn := it.Transaction()
+//	    ...
+//	}
+//	if err := it.Err(); err != nil {
+//	    ...
+//	}
+type TransactionIterator struct {
+	// ChunkSize is the max number of transactions per query. Servers may have a limit on it.
+	ChunkSize uint64
+
+	// Prefetch is the max number of chunks which are fetched ahead of the consumer. When
+	// this number is reached, fetching pauses until the consumer catches up.
+	Prefetch int
+
+	// PollInterval is the wait time before querying again, after the tip of the ledger
+	// is reached.
+	PollInterval time.Duration
+
+	c          *Client
+	ctx        context.Context
+	cancel     context.CancelFunc
+	from, to   uint64
+	withEvents bool
+
+	chunks  chan *txnChunk
+	started bool
+	closed  bool
+	pending []*types.ProvenTransaction
+	txn     *types.ProvenTransaction
+	err     error
+}
+
+type txnChunk struct {
+	txns []*types.ProvenTransaction
+	err  error
+}
+
+// NewTransactionIterator creates a TransactionIterator on versions in range [from, to).
+// If to is FollowTip, the iterator never ends, unless the context is done or an error occurs.
+//
+// Settings of the iterator can be changed before the first call to Next. The iterator
+// should be closed after use.
+func (c *Client) NewTransactionIterator(ctx context.Context, from, to uint64, withEvents bool) *TransactionIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	return &TransactionIterator{
+		ChunkSize:    DefaultTransactionChunkSize,
+		Prefetch:     DefaultTransactionPrefetch,
+		PollInterval: DefaultTransactionPollInterval,
+		c:            c,
+		ctx:          ctx,
+		cancel:       cancel,
+		from:         from,
+		to:           to,
+		withEvents:   withEvents,
+	}
+}
+
+// Next advances the iterator to the next transaction, which can then be retrieved by
+// Transaction(). It returns false when the iteration stops, either by reaching the end
+// of the range, or by an error. Err() should be checked after Next returns false.
+func (it *TransactionIterator) Next() bool {
+	if !it.started {
+		it.started = true
+		if it.ChunkSize == 0 {
+			it.err = errors.New("zero chunk size")
+			return false
+		}
+		it.chunks = make(chan *txnChunk, it.Prefetch)
+		go it.fetch()
+	}
+	if it.err != nil || it.closed {
+		return false
+	}
+	for len(it.pending) == 0 {
+		chunk, ok := <-it.chunks
+		if !ok {
+			it.txn = nil
+			it.err = it.ctx.Err()
+			return false
+		}
+		if chunk.err != nil {
+			it.txn = nil
+			it.err = chunk.err
+			return false
+		}
+		it.pending = chunk.txns
+	}
+	it.txn, it.pending = it.pending[0], it.pending[1:]
+	return true
+}
+
+// Transaction returns the current transaction.
+func (it *TransactionIterator) Transaction() *types.ProvenTransaction {
+	return it.txn
+}
+
+// Err returns the error which stops the iteration. It returns nil if the end of the
+// range is reached.
+func (it *TransactionIterator) Err() error {
+	return it.err
+}
+
+// Close stops the iteration, and releases the background fetching goroutine.
+func (it *TransactionIterator) Close() {
+	it.closed = true
+	it.cancel()
+	if it.started {
+		for range it.chunks {
+		}
+	}
+	it.pending = nil
+}
+
+func (it *TransactionIterator) fetch() {
+	defer close(it.chunks)
+	next := it.from
+	for next < it.to {
+		limit := it.ChunkSize
+		if it.to-next < limit {
+			limit = it.to - next
+		}
+		txns, err := it.fetchChunk(next, limit)
+		if err != nil {
+			if it.ctx.Err() == nil {
+				it.chunks <- &txnChunk{err: err}
+			}
+			return
+		}
+		if len(txns) == 0 {
+			timer := time.NewTimer(it.PollInterval)
+			select {
+			case <-timer.C:
+				continue
+			case <-it.ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+		select {
+		case it.chunks <- &txnChunk{txns: txns}:
+		case <-it.ctx.Done():
+			return
+		}
+		next += uint64(len(txns))
+	}
+}
+
+// fetchChunk queries up to limit transactions from version start, and makes sure that
+// no committed transaction in range is missing.
+func (it *TransactionIterator) fetchChunk(start, limit uint64) ([]*types.ProvenTransaction, error) {
+	ptl, err := it.c.QueryTransactionRange(it.ctx, start, limit, it.withEvents)
+	if err != nil {
+		return nil, err
+	}
+	expected := uint64(0)
+	if ledgerVersion := ptl.GetLedgerInfo().GetVersion(); start <= ledgerVersion {
+		expected = ledgerVersion - start + 1
+	}
+	if expected > limit {
+		expected = limit
+	}
+	txns := ptl.GetTransactions()
+	if uint64(len(txns)) != expected {
+		return nil, fmt.Errorf("incomplete transaction list: expecting %d transactions from version %d, got %d", expected, start, len(txns))
+	}
+	if len(txns) > 0 && txns[0].GetVersion() != start {
+		return nil, fmt.Errorf("unexpected first transaction version: expecting %d, got %d", start, txns[0].GetVersion())
+	}
+	return txns, nil
+}
@@ -0,0 +1,83 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/libratest"
+)
+
+func TestTransactionIterator(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	for round := uint64(10); round < 30; round++ {
+		libratest.CommitBlock(t, l, round)
+	}
+	_, c := libratest.StartServer(t, l)
+
+	newIterator := func(ctx context.Context, c *client.Client, from, to uint64) *client.TransactionIterator {
+		it := c.NewTransactionIterator(ctx, from, to, true)
+		it.ChunkSize = 4
+		it.Prefetch = 1
+		it.PollInterval = 10 * time.Millisecond
+		t.Cleanup(it.Close)
+		return it
+	}
+
+	t.Run("range", func(t *testing.T) {
+		it := newIterator(ctx, c, 2, 19)
+		version := uint64(2)
+		for it.Next() {
+			assert.Equal(t, version, it.Transaction().GetVersion())
+			version++
+		}
+		require.NoError(t, it.Err())
+		assert.Equal(t, uint64(19), version)
+	})
+
+	t.Run("follow tip", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		it := newIterator(ctx, c, l.Version()-1, client.FollowTip)
+		for _, expected := range []uint64{l.Version() - 1, l.Version()} {
+			require.True(t, it.Next())
+			assert.Equal(t, expected, it.Transaction().GetVersion())
+		}
+
+		libratest.CommitBlock(t, l, 100)
+		require.True(t, it.Next())
+		assert.Equal(t, l.Version(), it.Transaction().GetVersion())
+
+		cancel()
+		for it.Next() {
+		}
+		assert.Equal(t, context.Canceled, it.Err())
+	})
+
+	t.Run("close", func(t *testing.T) {
+		it := newIterator(ctx, c, 0, client.FollowTip)
+		require.True(t, it.Next())
+		it.Close()
+		assert.False(t, it.Next())
+		assert.NoError(t, it.Err())
+	})
+
+	t.Run("incomplete chunk", func(t *testing.T) {
+		s, c := libratest.StartServer(t, l)
+		s.TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			txnList := resp.ResponseItems[0].GetGetTransactionsResponse().TxnListWithProof
+			txnList.Transactions = txnList.Transactions[:0]
+			txnList.Proof.TransactionInfos = txnList.Proof.TransactionInfos[:0]
+			txnList.Proof.LedgerInfoToTransactionInfosProof = nil
+		}
+		it := newIterator(ctx, c, 0, 10)
+		assert.False(t, it.Next())
+		assert.Error(t, it.Err())
+	})
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/the729/go-libra/types"
+)
+
+// PollPolicy controls how often the client polls the ledger, while waiting for something
+// to happen, e.g. in PollSequenceUntil, WaitAccountState and SubmitAndWait.
+type PollPolicy struct {
+	// Interval is the wait time between polls.
+	Interval time.Duration
+
+	// MaxInterval enables adaptive polling, if it is greater than Interval. Whenever a poll
+	// sees no new ledger version, the wait time doubles, up to MaxInterval. It is reset to
+	// Interval once the ledger advances.
+	MaxInterval time.Duration
+}
+
+// DefaultPollPolicy returns a poll policy with fixed 1s interval.
+func DefaultPollPolicy() *PollPolicy {
+	return &PollPolicy{
+		Interval: time.Second,
+	}
+}
+
+// SetPollPolicy sets the poll policy of the client. A nil policy means the default
+// poll policy.
+func (c *Client) SetPollPolicy(p *PollPolicy) {
+	c.epMu.Lock()
+	defer c.epMu.Unlock()
+	c.pollPolicy = p
+}
+
+// ErrExpired is the reason of a WaitError, when the ledger timestamp passes the expiration time.
+var ErrExpired = errors.New("expired")
+
+// WaitError is returned when waiting on an account fails. It reports the last proven state
+// of the account.
+type WaitError struct {
+	// Err is the reason of the failure, which is ErrExpired, the context error, or a query error.
+	Err error
+
+	// LastState is the last proven state of the account. It is nil if no query succeeded.
+	LastState *types.ProvenAccountState
+}
+
+// SequenceNumber returns the sequence number of the account in the last proven state.
+// It returns 0 if the account does not exist, or there is no last proven state.
+func (e *WaitError) SequenceNumber() uint64 {
+	if e.LastState == nil || e.LastState.IsNil() {
+		return 0
+	}
+	seq, _ := accountSequenceNumber(e.LastState)
+	return seq
+}
+
+// Version returns the ledger version of the last proven state, or 0 if there is none.
+func (e *WaitError) Version() uint64 {
+	if e.LastState == nil {
+		return 0
+	}
+	return e.LastState.GetLedgerInfo().GetVersion()
+}
+
+func (e *WaitError) Error() string {
+	if e.LastState == nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v: last sequence number %d at ledger version %d", e.Err, e.SequenceNumber(), e.Version())
+}
+
+// WaitAccountState blocks to repeatedly poll the state of an account, until the predicate
+// returns true on a proven state, which is then returned.
+//
+// It fails with a *WaitError if the predicate returns an error, the context is done, a query
+// fails, or the ledger timestamp passes the expiration time. A zero expiration time means
+// no expiration. Transient RPC failures are retried under the poll policy, until the context
+// is done.
+func (c *Client) WaitAccountState(
+	ctx context.Context, addr types.AccountAddress, expiration time.Time,
+	predicate func(*types.ProvenAccountState) (bool, error),
+) (*types.ProvenAccountState, error) {
+	p := c.newPoller()
+	var last *types.ProvenAccountState
+	for {
+		paccount, err := c.QueryAccountState(ctx, addr)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, &WaitError{Err: ctx.Err(), LastState: last}
+			}
+			if !c.transient(err) {
+				return nil, &WaitError{Err: err, LastState: last}
+			}
+			if err := p.wait(ctx, p.lastVersion); err != nil {
+				return nil, &WaitError{Err: err, LastState: last}
+			}
+			continue
+		}
+		last = paccount
+		ok, err := predicate(paccount)
+		if err != nil {
+			return nil, &WaitError{Err: err, LastState: last}
+		}
+		if ok {
+			return paccount, nil
+		}
+		ledgerInfo := paccount.GetLedgerInfo()
+		if !expiration.IsZero() && ledgerInfo.GetTimestampUsec() > uint64(expiration.Unix()+1)*1000000 {
+			return nil, &WaitError{Err: ErrExpired, LastState: last}
+		}
+		if err := p.wait(ctx, ledgerInfo.GetVersion()); err != nil {
+			return nil, &WaitError{Err: err, LastState: last}
+		}
+	}
+}
+
+type poller struct {
+	policy      PollPolicy
+	interval    time.Duration
+	lastVersion uint64
+}
+
+func (c *Client) newPoller() *poller {
+	c.epMu.Lock()
+	p := c.pollPolicy
+	c.epMu.Unlock()
+	if p == nil {
+		p = DefaultPollPolicy()
+	}
+	return &poller{policy: *p}
+}
+
+// wait blocks before the next poll, given the ledger version seen in the current poll.
+func (p *poller) wait(ctx context.Context, version uint64) error {
+	if p.interval == 0 || version != p.lastVersion {
+		p.interval = p.policy.Interval
+	} else if p.interval < p.policy.MaxInterval {
+		p.interval *= 2
+		if p.interval > p.policy.MaxInterval {
+			p.interval = p.policy.MaxInterval
+		}
+	}
+	p.lastVersion = version
+
+	timer := time.NewTimer(p.interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
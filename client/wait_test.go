@@ -0,0 +1,98 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestWaitAccountState(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	s, c := libratest.StartServer(t, l)
+	c.SetPollPolicy(&client.PollPolicy{Interval: 5 * time.Millisecond, MaxInterval: 20 * time.Millisecond})
+	libratest.Transfer(t, c, alice, bob, 0, 10)
+
+	t.Run("sequence number", func(t *testing.T) {
+		err := c.PollSequenceUntil(ctx, alice.Address, 1, time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		err := c.PollSequenceUntil(ctx, alice.Address, 5, time.Now().Add(time.Minute))
+		require.Error(t, err)
+		werr, ok := err.(*client.WaitError)
+		require.True(t, ok, err.Error())
+		assert.Equal(t, context.DeadlineExceeded, werr.Err)
+		assert.Equal(t, uint64(1), werr.SequenceNumber())
+		assert.Equal(t, l.Version(), werr.Version())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		err := c.PollSequenceUntil(ctx, alice.Address, 5, time.Now().Add(-time.Minute))
+		require.Error(t, err)
+		werr, ok := err.(*client.WaitError)
+		require.True(t, ok, err.Error())
+		assert.Equal(t, client.ErrExpired, werr.Err)
+	})
+
+	t.Run("transient failures", func(t *testing.T) {
+		code, failures := codes.Unavailable, 2
+		flaky := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			if failures > 0 {
+				failures--
+				return status.Error(code, "injected failure")
+			}
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		_, addrs := libratest.StartServers(t, l)
+		c, err := client.NewWithOptions(addrs[0], l.Waypoint(), client.WithUnaryInterceptors(flaky))
+		require.NoError(t, err)
+		defer c.Close()
+		c.SetPollPolicy(&client.PollPolicy{Interval: 5 * time.Millisecond})
+
+		err = c.PollSequenceUntil(ctx, alice.Address, 1, time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, failures)
+
+		code, failures = codes.InvalidArgument, 1
+		err = c.PollSequenceUntil(ctx, alice.Address, 1, time.Now().Add(time.Minute))
+		require.Error(t, err)
+		werr, ok := err.(*client.WaitError)
+		require.True(t, ok, err.Error())
+		assert.Equal(t, codes.InvalidArgument, status.Code(werr.Err))
+	})
+
+	t.Run("predicate", func(t *testing.T) {
+		s.HoldTransactions = true
+		libratest.Transfer(t, c, alice, bob, 1, 10)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			assert.NoError(t, s.Flush())
+		}()
+
+		paccount, err := c.WaitAccountState(ctx, bob.Address, time.Time{}, func(paccount *types.ProvenAccountState) (bool, error) {
+			br, err := paccount.GetAccountBlob().GetLibraBalanceResource()
+			if err != nil {
+				return false, err
+			}
+			return br.Coin >= 20, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, l.Version(), paccount.GetLedgerInfo().GetVersion())
+	})
+}
@@ -0,0 +1,136 @@
+// Package bech32 implements the bech32 encoding of BIP-0173.
+package bech32
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// MaxLength is the max length of a bech32 string.
+const MaxLength = 90
+
+var generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func hrpExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func checksum(hrp string, data []byte) []byte {
+	values := append(hrpExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := polymod(values) ^ 1
+	out := make([]byte, 6)
+	for i := range out {
+		out[i] = byte(mod>>uint(5*(5-i))) & 31
+	}
+	return out
+}
+
+// Encode encodes 5-bit groups of data with a human readable part into a bech32 string.
+func Encode(hrp string, data []byte) (string, error) {
+	if len(hrp) == 0 || len(hrp)+len(data)+7 > MaxLength {
+		return "", fmt.Errorf("invalid length of bech32 string")
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 || (hrp[i] >= 'A' && hrp[i] <= 'Z') {
+			return "", fmt.Errorf("invalid character %q in human readable part", hrp[i])
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range append(append([]byte{}, data...), checksum(hrp, data)...) {
+		if d >= 32 {
+			return "", fmt.Errorf("invalid 5-bit group %d", d)
+		}
+		sb.WriteByte(charset[d])
+	}
+	return sb.String(), nil
+}
+
+// Decode decodes a bech32 string into the human readable part and 5-bit groups of data,
+// and verifies the checksum. Mixed case strings are rejected.
+func Decode(s string) (string, []byte, error) {
+	if len(s) > MaxLength {
+		return "", nil, errors.New("bech32 string too long")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, errors.New("mixed case in bech32 string")
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, errors.New("invalid separator position in bech32 string")
+	}
+	hrp := s[:pos]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, fmt.Errorf("invalid character %q in human readable part", hrp[i])
+		}
+	}
+	data := make([]byte, 0, len(s)-pos-1)
+	for i := pos + 1; i < len(s); i++ {
+		d := strings.IndexByte(charset, s[i])
+		if d < 0 {
+			return "", nil, fmt.Errorf("invalid character %q in bech32 string", s[i])
+		}
+		data = append(data, byte(d))
+	}
+	if polymod(append(hrpExpand(hrp), data...)) != 1 {
+		return "", nil, errors.New("bech32 checksum mismatch")
+	}
+	return hrp, data[:len(data)-6], nil
+}
+
+// ConvertBits regroups data from groups of fromBits into groups of toBits. With pad, the
+// last group is padded with zeros. Without pad, the leftover bits must be zeros, and fewer
+// than fromBits.
+func ConvertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var acc, bits uint
+	maxv := uint(1)<<toBits - 1
+	var out []byte
+	for _, b := range data {
+		if uint(b)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid %d-bit group %d", fromBits, b)
+		}
+		acc = acc<<fromBits | uint(b)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	return out, nil
+}
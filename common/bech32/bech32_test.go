@@ -0,0 +1,59 @@
+package bech32
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBech32(t *testing.T) {
+	// test vectors from BIP-0173
+	valid := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+	}
+	for _, s := range valid {
+		hrp, data, err := Decode(s)
+		require.NoError(t, err, s)
+		out, err := Encode(hrp, data)
+		require.NoError(t, err, s)
+		assert.Equal(t, strings.ToLower(s), out)
+	}
+
+	invalid := []string{
+		"\x201nwldj5",
+		"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx",
+		"pzry9x0s0muk",
+		"1pzry9x0s0muk",
+		"x1b4n0q5v",
+		"li1dgmt3",
+		"A1G7SGD8",
+		"10a06t8",
+		"1qzzfhee",
+		"a12UEL5L",
+	}
+	for _, s := range invalid {
+		_, _, err := Decode(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestConvertBits(t *testing.T) {
+	data := []byte{0xff, 0x00, 0xa5}
+	five, err := ConvertBits(data, 8, 5, true)
+	require.NoError(t, err)
+	assert.Len(t, five, 5)
+	out, err := ConvertBits(five, 5, 8, false)
+	require.NoError(t, err)
+	assert.Equal(t, data, out)
+
+	_, err = ConvertBits([]byte{32}, 5, 8, true)
+	assert.Error(t, err)
+	_, err = ConvertBits([]byte{1}, 5, 8, false)
+	assert.Error(t, err)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/BurntSushi/toml"
 	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/types"
 )
 
 func saveClientState(c *client.Client, filepath string) error {
@@ -23,14 +24,22 @@ func saveClientState(c *client.Client, filepath string) error {
 }
 
 func newClientFromWaypointOrFile(serverAddr, waypoint, filepath string) (*client.Client, error) {
+	var c *client.Client
+	var err error
 	if waypoint != "" {
-		return client.New(serverAddr, waypoint)
+		c, err = client.New(serverAddr, waypoint)
+	} else {
+		v := &client.State{}
+		if _, err := toml.DecodeFile(filepath, v); err != nil {
+			return nil, fmt.Errorf("toml decode file error: %v", err)
+		}
+		c, err = client.NewFromState(serverAddr, v)
 	}
-
-	v := &client.State{}
-	_, err := toml.DecodeFile(filepath, v)
 	if err != nil {
-		return nil, fmt.Errorf("toml decode file error: %v", err)
+		return nil, err
 	}
-	return client.NewFromState(serverAddr, v)
+	c.SetEpochCatchUpFunc(func(epochChange *types.ProvenLedgerInfo, targetEpoch uint64) {
+		log.Printf("Catching up epochs: %d of %d", epochChange.GetEpochNum()+1, targetEpoch)
+	})
+	return c, nil
 }
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"io/ioutil"
@@ -10,12 +9,10 @@ import (
 	"os"
 	"strconv"
 
-	"github.com/BurntSushi/toml"
 	"github.com/urfave/cli"
-	"golang.org/x/crypto/ed25519"
-	"golang.org/x/crypto/sha3"
 
-	"github.com/the729/go-libra/crypto"
+	"github.com/the729/go-libra/types"
+	"github.com/the729/go-libra/wallet"
 )
 
 func cmdCreateAccounts(ctx *cli.Context) error {
@@ -29,31 +26,18 @@ func cmdCreateAccounts(ctx *cli.Context) error {
 		number = 10
 	}
 	log.Printf("generating %d accounts...", number)
-	wallet := &WalletConfig{}
+	w, err := wallet.Generate(nil)
+	if err != nil {
+		return err
+	}
+	var accounts []*wallet.Account
 	for i := 0; i < number; i++ {
-		pubkey, prikey, err := ed25519.GenerateKey(rand.Reader)
-		if err != nil {
-			return err
-		}
-		hasher := sha3.New256()
-		hasher.Write(pubkey)
-		account := &AccountConfig{
-			PrivateKey: crypto.PrivateKey(prikey),
-		}
-		hasher.Sum(account.Address[:0])
-		wallet.Accounts = append(wallet.Accounts, account)
+		accounts = append(accounts, w.Account(uint64(i)))
 	}
-
-	f, err := os.Create(WalletFile)
-	if err != nil {
-		log.Printf("cannot create wallet file in %s", WalletFile)
+	if err := saveHDWallet(WalletFile, w, accounts); err != nil {
+		log.Print(err)
 		return nil
 	}
-	defer f.Close()
-	err = toml.NewEncoder(f).Encode(wallet)
-	if err != nil {
-		log.Printf("cannot encode toml file: %v", err)
-	}
 
 	return cmdListAccounts(ctx)
 }
@@ -65,9 +49,10 @@ func cmdListAccounts(ctx *cli.Context) error {
 	}
 
 	for addr, account := range wallet.Accounts {
-		log.Printf("account: %s   authkey prefix: %s   prikey prefix: %s\n",
-			addr,
-			hex.EncodeToString(account.AuthKey[:16]),
+		id := &types.AccountIdentifier{Prefix: types.TestnetPrefix, Address: account.Address}
+		log.Printf("account: %s (%s)   authkey prefix: %s   prikey prefix: %s\n",
+			addr, id,
+			hex.EncodeToString(account.AuthKey.Prefix()),
 			hex.EncodeToString(account.PrivateKey[:4]),
 		)
 	}
@@ -92,7 +77,7 @@ func cmdMint(ctx *cli.Context) error {
 	}
 	amountMicro := uint64(amount) * 1000000
 
-	faucetURL := fmt.Sprintf("http://faucet.testnet.libra.org/?amount=%d&auth_key=%s", amountMicro, hex.EncodeToString(receiver.AuthKey))
+	faucetURL := fmt.Sprintf("http://faucet.testnet.libra.org/?amount=%d&auth_key=%s", amountMicro, hex.EncodeToString(receiver.AuthKey[:]))
 	log.Printf("Going to POST to faucet service: %s", faucetURL)
 
 	resp, err := http.PostForm(faucetURL, nil)
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli"
+
+	"github.com/the729/go-libra/wallet"
+)
+
+// saveHDWallet saves the mnemonic, seed and derived accounts of a hierarchical deterministic
+// wallet into a new wallet file. If the file name ends with .json, an encrypted keystore
+// is saved. Otherwise, a plaintext toml file is saved.
+func saveHDWallet(file string, w *wallet.Wallet, accounts []*wallet.Account) error {
+	if isKeystoreFile(file) {
+		return saveKeystore(file, &wallet.Keystore{Mnemonic: w.Mnemonic(), Seed: w.Seed(), Accounts: accounts})
+	}
+
+	conf := &WalletConfig{
+		Mnemonic: w.Mnemonic(),
+		Seed:     w.Seed(),
+	}
+	for _, a := range accounts {
+		conf.Accounts = append(conf.Accounts, newAccountConfig(a))
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot create wallet file: %v", err)
+	}
+	defer f.Close()
+	if err := toml.NewEncoder(f).Encode(conf); err != nil {
+		return fmt.Errorf("cannot encode toml file: %v", err)
+	}
+	return nil
+}
+
+func cmdRecoverAccounts(ctx *cli.Context) error {
+	if _, err := os.Stat(WalletFile); err == nil {
+		log.Printf("wallet file (%s) already exists.", WalletFile)
+		return nil
+	}
+
+	gapLimit := wallet.DefaultGapLimit
+	if ctx.Args().Get(0) != "" {
+		var err error
+		if gapLimit, err = strconv.Atoi(ctx.Args().Get(0)); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprint(os.Stderr, "Enter mnemonic: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return err
+	}
+	w, err := wallet.NewFromMnemonic(line)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClientFromWaypointOrFile(ServerAddr, TrustedWaypoint, KnownVersionFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+	defer saveClientState(c, KnownVersionFile)
+
+	log.Printf("scanning accounts with gap limit %d...", gapLimit)
+	recovered, err := w.Recover(context.Background(), c, gapLimit)
+	if err != nil {
+		return err
+	}
+	var accounts []*wallet.Account
+	for _, a := range recovered {
+		accounts = append(accounts, a.Account)
+	}
+	log.Printf("found %d accounts on the ledger", len(accounts))
+	if len(accounts) == 0 {
+		accounts = append(accounts, w.Account(0))
+	}
+
+	if err := saveHDWallet(WalletFile, w, accounts); err != nil {
+		return err
+	}
+	return cmdListAccounts(ctx)
+}
+
+func cmdExportMnemonic(ctx *cli.Context) error {
+	sw, err := LoadAccounts(WalletFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if sw.Mnemonic == "" {
+		return errors.New("wallet file has no mnemonic, accounts are not recoverable from mnemonic")
+	}
+	fmt.Println(sw.Mnemonic)
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/the729/go-libra/crypto"
+	"github.com/the729/go-libra/wallet"
+)
+
+// passphraseEnv is the environment variable of the keystore passphrase. If it is not set,
+// the passphrase is prompted.
+const passphraseEnv = "LIBRA_WALLET_PASSPHRASE"
+
+const defaultKeystoreFile = "wallet.json"
+
+// isKeystoreFile returns whether the wallet file is an encrypted keystore, instead of
+// a plaintext toml file.
+func isKeystoreFile(file string) bool {
+	if strings.HasSuffix(file, ".json") {
+		return true
+	}
+	data, err := ioutil.ReadFile(file)
+	return err == nil && bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+func readPassphrase(confirm bool) ([]byte, error) {
+	if p, ok := os.LookupEnv(passphraseEnv); ok {
+		return []byte(p), nil
+	}
+	read := func(prompt string) ([]byte, error) {
+		fmt.Fprint(os.Stderr, prompt)
+		if terminal.IsTerminal(int(os.Stdin.Fd())) {
+			defer fmt.Fprintln(os.Stderr)
+			return terminal.ReadPassword(int(os.Stdin.Fd()))
+		}
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return nil, err
+		}
+		return []byte(strings.TrimRight(line, "\r\n")), nil
+	}
+	p, err := read("Enter wallet passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		if len(p) == 0 {
+			return nil, errors.New("empty passphrase")
+		}
+		p2, err := read("Repeat wallet passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(p, p2) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return p, nil
+}
+
+func loadKeystoreAccounts(file string) (*SimpleWallet, error) {
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	ks, err := wallet.LoadKeystore(file, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	sw := &SimpleWallet{
+		Mnemonic: ks.Mnemonic,
+		Seed:     ks.Seed,
+		Accounts: make(map[string]*Account),
+	}
+	for _, a := range ks.Accounts {
+		sw.Accounts[hex.EncodeToString(a.Address[:])] = &Account{
+			PrivateKey: a.PrivateKey,
+			Address:    a.Address,
+			AuthKey:    a.AuthKey,
+		}
+	}
+	return sw, nil
+}
+
+// saveKeystore encrypts accounts with a new passphrase, and saves them into a new
+// keystore file.
+func saveKeystore(file string, ks *wallet.Keystore) error {
+	if _, err := os.Stat(file); err == nil {
+		return fmt.Errorf("keystore file (%s) already exists", file)
+	}
+	passphrase, err := readPassphrase(true)
+	if err != nil {
+		return err
+	}
+	return wallet.SaveKeystore(file, ks, passphrase, nil)
+}
+
+func cmdEncryptWallet(ctx *cli.Context) error {
+	if isKeystoreFile(WalletFile) {
+		return fmt.Errorf("wallet file (%s) is already encrypted", WalletFile)
+	}
+	sw, err := LoadAccounts(WalletFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ks := &wallet.Keystore{Mnemonic: sw.Mnemonic, Seed: sw.Seed}
+	var w *wallet.Wallet
+	if len(sw.Seed) > 0 || sw.Mnemonic != "" {
+		if w, err = ks.Wallet(); err != nil {
+			return err
+		}
+	}
+	for addr, account := range sw.Accounts {
+		if account.PrivateKey == nil {
+			log.Printf("skip account %s without private key", addr)
+			continue
+		}
+		a, err := wallet.NewAccount(account.PrivateKey)
+		if err != nil {
+			return err
+		}
+		if w != nil {
+			if index, ok := findChildIndex(w, a, uint64(len(sw.Accounts))+wallet.DefaultGapLimit); ok {
+				a.Index = index
+			}
+		}
+		ks.Accounts = append(ks.Accounts, a)
+	}
+
+	file := ctx.Args().Get(0)
+	if file == "" {
+		file = defaultKeystoreFile
+	}
+	if err := saveKeystore(file, ks); err != nil {
+		return err
+	}
+	log.Printf("%d accounts are encrypted into %s. Use it with '-w %s', and securely delete %s.",
+		len(ks.Accounts), file, file, WalletFile)
+	return nil
+}
+
+// findChildIndex finds the child index of an account derived from the wallet, searching
+// up to limit. It returns false if the account is not found, e.g. an imported one.
+func findChildIndex(w *wallet.Wallet, a *wallet.Account, limit uint64) (uint64, bool) {
+	for i := uint64(0); i < limit; i++ {
+		if w.Account(i).Address == a.Address {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// newAccountConfig converts a wallet account into the plaintext toml format.
+func newAccountConfig(a *wallet.Account) *AccountConfig {
+	return &AccountConfig{
+		PrivateKey: crypto.PrivateKey(a.PrivateKey),
+		AuthKey:    crypto.PublicKey(a.AuthKey[:]),
+		Address:    append([]byte{}, a.Address[:]...),
+	}
+}
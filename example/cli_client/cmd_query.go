@@ -203,12 +203,13 @@ func cmdQueryEvents(ctx *cli.Context) error {
 	default:
 		return fmt.Errorf("unknown ordering: %s, should be either asc or desc", evType)
 	}
-	evs, err := c.QueryEventsByAccessPath(context.Background(), ap, uint64(start), ascending, uint64(limit))
+	evList, err := c.QueryEventListByAccessPath(context.Background(), ap, uint64(start), ascending, uint64(limit))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	for i, ev := range evs {
+	log.Printf("Total number of events: %d", evList.GetTotalCount())
+	for i, ev := range evList.GetEvents() {
 		log.Printf("#%d: txn #%d event #%d", i, ev.GetTransactionVersion(), ev.GetEventIndex())
 		evBody := ev.GetEvent().Value.(*types.ContractEventV0)
 		log.Printf("    Key: %s", hex.EncodeToString(evBody.Key))
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/urfave/cli"
+
+	"github.com/the729/go-libra/signer"
+)
+
+const defaultSignerListenAddr = "127.0.0.1:8765"
+
+func cmdServeSigner(ctx *cli.Context) error {
+	wallet, err := LoadAccounts(WalletFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	account, err := wallet.GetAccount(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	if account.PrivateKey == nil {
+		return fmt.Errorf("private key of %s not present in local config file", hex.EncodeToString(account.Address[:]))
+	}
+	mem, err := signer.NewMemory(account.PrivateKey)
+	if err != nil {
+		return err
+	}
+	srv, err := signer.NewServer(mem)
+	if err != nil {
+		return err
+	}
+
+	listenAddr := ctx.Args().Get(1)
+	if listenAddr == "" {
+		listenAddr = defaultSignerListenAddr
+	}
+	log.Printf("Serving signer of %s at http://%s", hex.EncodeToString(account.Address[:]), listenAddr)
+	return http.ListenAndServe(listenAddr, srv)
+}
@@ -1,15 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"fmt"
 	"log"
 	"strconv"
 	"time"
 
 	"github.com/urfave/cli"
+	"golang.org/x/crypto/ed25519"
 
 	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/signer"
+	"github.com/the729/go-libra/types"
 )
 
 func cmdTransfer(ctx *cli.Context) error {
@@ -33,6 +38,10 @@ func cmdTransfer(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	if !receiver.SubAddress.IsZero() {
+		// The peer-to-peer script does not carry metadata, so the subaddress would be lost.
+		return fmt.Errorf("subaddress %s of receiver cannot be attached to the transaction", hex.EncodeToString(receiver.SubAddress[:]))
+	}
 
 	amount, err := strconv.Atoi(ctx.Args().Get(2))
 	if err != nil {
@@ -67,23 +76,59 @@ func cmdTransfer(ctx *cli.Context) error {
 	log.Printf("Max gas: %d, Gas price: %d, Expiration: %v", maxGasAmount, gasUnitPrice, expiration)
 
 	log.Printf("Get current account sequence of sender...")
-	seq, err := c.QueryAccountSequenceNumber(context.Background(), sender.Address)
+	senderState, err := c.QueryAccountState(context.Background(), sender.Address)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if senderState.IsNil() {
+		return fmt.Errorf("sender account %s does not exist", hex.EncodeToString(sender.Address[:]))
+	}
+	senderResource, err := senderState.GetAccountBlob().GetLibraAccountResource()
+	if err != nil {
+		log.Fatal(err)
+	}
+	seq := senderResource.SequenceNumber
 	log.Printf("... is %d", seq)
 
+	// The auth key prefix of a receiver not in the wallet is unknown, which is fine
+	// if the receiver account already exists.
+	var recvAuthKeyPrefix []byte
+	if receiver.AuthKey != (types.AuthenticationKey{}) {
+		recvAuthKeyPrefix = receiver.AuthKey.Prefix()
+	}
 	rawTxn, err := client.NewRawP2PTransaction(
-		sender.Address, receiver.Address, receiver.AuthKey[0:16],
+		sender.Address, receiver.Address, recvAuthKeyPrefix,
 		seq, amountMicro, maxGasAmount, gasUnitPrice, expiration,
 	)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	var txnSigner types.Signer = sender.PrivateKey
+	if SignerURL != "" {
+		if txnSigner, err = signer.NewRemote(SignerURL, nil); err != nil {
+			log.Fatal(err)
+		}
+		// fail early on a misconfigured signer, instead of an opaque rejection
+		pubkey, _ := txnSigner.Public().(ed25519.PublicKey)
+		authKey, err := types.Ed25519AuthenticationKey(pubkey)
+		if err != nil {
+			return fmt.Errorf("remote signer public key error: %v", err)
+		}
+		// compare with the on-chain key, which differs from the address after key rotation
+		if !bytes.Equal(authKey[:], senderResource.AuthenticationKey) {
+			return fmt.Errorf("remote signer key %s does not belong to sender %s",
+				hex.EncodeToString(pubkey), hex.EncodeToString(sender.Address[:]))
+		}
+	} else if sender.PrivateKey == nil {
+		return fmt.Errorf("private key of %s not present in local config file", hex.EncodeToString(sender.Address[:]))
+	}
+
 	log.Printf("Submit transaction...")
-	expectedSeq, err := c.SubmitRawTransaction(context.Background(), rawTxn, sender.PrivateKey)
-	if err != nil {
+	expectedSeq, err := c.SubmitRawTransaction(context.Background(), rawTxn, txnSigner)
+	if err == client.ErrSubmissionUnconfirmed {
+		log.Printf("%v, waiting for it anyway", err)
+	} else if err != nil {
 		log.Fatal(err)
 	}
 
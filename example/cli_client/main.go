@@ -14,7 +14,7 @@ const (
 	knownVersionFile = "client_state.toml"
 )
 
-var ServerAddr, TrustedWaypoint, WalletFile, KnownVersionFile string
+var ServerAddr, TrustedWaypoint, WalletFile, KnownVersionFile, SignerURL string
 
 func main() {
 	app := cli.NewApp()
@@ -34,7 +34,7 @@ func main() {
 		cli.StringFlag{
 			Name:        "wallet, w",
 			Value:       walletFile,
-			Usage:       "load or store account private keys in `FILE`",
+			Usage:       "load or store account private keys in `FILE`, encrypted if it is a .json keystore",
 			Destination: &WalletFile,
 		},
 		cli.StringFlag{
@@ -43,6 +43,11 @@ func main() {
 			Usage:       "load or store client state in `FILE`",
 			Destination: &KnownVersionFile,
 		},
+		cli.StringFlag{
+			Name:        "signer, s",
+			Usage:       "sign transactions with remote signer at `URL`, instead of private keys in wallet",
+			Destination: &SignerURL,
+		},
 	}
 	app.Commands = []cli.Command{
 		{
@@ -102,6 +107,20 @@ func main() {
 					Aliases: []string{"m"},
 					Action:  cmdMint,
 				},
+				{
+					Name:   "recover",
+					Usage:  "[gap_limit]",
+					Action: cmdRecoverAccounts,
+				},
+				{
+					Name:   "export-mnemonic",
+					Action: cmdExportMnemonic,
+				},
+				{
+					Name:   "encrypt",
+					Usage:  "[keystore_file]",
+					Action: cmdEncryptWallet,
+				},
 			},
 		},
 		{
@@ -110,6 +129,16 @@ func main() {
 			Aliases: []string{"t"},
 			Action:  cmdTransfer,
 		},
+		{
+			Name: "signer",
+			Subcommands: []cli.Command{
+				{
+					Name:   "serve",
+					Usage:  "address_prefix [listen_address]",
+					Action: cmdServeSigner,
+				},
+			},
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -7,7 +7,6 @@ import (
 
 	"github.com/BurntSushi/toml"
 	"golang.org/x/crypto/ed25519"
-	"golang.org/x/crypto/sha3"
 
 	"github.com/the729/go-libra/crypto"
 	"github.com/the729/go-libra/types"
@@ -16,8 +15,11 @@ import (
 type Account struct {
 	PrivateKey     ed25519.PrivateKey
 	Address        types.AccountAddress
-	AuthKey        ed25519.PublicKey
+	AuthKey        types.AuthenticationKey
 	SequenceNumber uint64
+
+	// SubAddress is set if the account is given as an account identifier with a subaddress.
+	SubAddress types.SubAddress
 }
 
 type AccountConfig struct {
@@ -27,14 +29,43 @@ type AccountConfig struct {
 }
 
 type WalletConfig struct {
+	// Mnemonic is the mnemonic of the master seed. It is empty in wallets created by
+	// older versions.
+	Mnemonic string `toml:"mnemonic,omitempty"`
+
+	// Seed is the master seed of a hierarchical deterministic wallet, from which
+	// the accounts are derived. It is empty in wallets created by older versions.
+	Seed     HexBytes         `toml:"seed,omitempty"`
 	Accounts []*AccountConfig `toml:"accounts"`
 }
 
 type SimpleWallet struct {
+	Mnemonic string
+	Seed     []byte
 	Accounts map[string]*Account
 }
 
+// HexBytes is a byte slice encoded as hex string in toml.
+type HexBytes []byte
+
+func (b *HexBytes) UnmarshalText(txt []byte) error {
+	data, err := hex.DecodeString(string(txt))
+	if err != nil {
+		return err
+	}
+	*b = data
+	return nil
+}
+
+func (b HexBytes) MarshalText() (text []byte, err error) {
+	return []byte(hex.EncodeToString(b)), nil
+}
+
 func LoadAccounts(file string) (*SimpleWallet, error) {
+	if isKeystoreFile(file) {
+		return loadKeystoreAccounts(file)
+	}
+
 	walletConf := &WalletConfig{}
 	_, err := toml.DecodeFile(file, walletConf)
 	if err != nil {
@@ -42,24 +73,25 @@ func LoadAccounts(file string) (*SimpleWallet, error) {
 	}
 
 	wallet := &SimpleWallet{
+		Mnemonic: walletConf.Mnemonic,
+		Seed:     walletConf.Seed,
 		Accounts: make(map[string]*Account),
 	}
 	for _, accountConf := range walletConf.Accounts {
 		account := &Account{
 			PrivateKey: ed25519.PrivateKey(accountConf.PrivateKey),
-			AuthKey:    ed25519.PublicKey(accountConf.AuthKey),
 		}
+		copy(account.AuthKey[:], accountConf.AuthKey)
 		if len(accountConf.Address) == types.AccountAddressLength {
 			copy(account.Address[:], accountConf.Address)
 		}
 		if accountConf.PrivateKey != nil {
 			pubkey := account.PrivateKey.Public().(ed25519.PublicKey)
-			hasher := sha3.New256()
-			hasher.Write(pubkey)
-			hasher.Write([]byte{0})
-			account.AuthKey = hasher.Sum([]byte{})
+			if account.AuthKey, err = types.Ed25519AuthenticationKey(pubkey); err != nil {
+				return nil, err
+			}
 			if len(accountConf.Address) != types.AccountAddressLength {
-				copy(account.Address[:], account.AuthKey[16:])
+				account.Address = account.AuthKey.DerivedAddress()
 			}
 		}
 		wallet.Accounts[hex.EncodeToString(account.Address[:])] = account
@@ -68,6 +100,16 @@ func LoadAccounts(file string) (*SimpleWallet, error) {
 }
 
 func (w *SimpleWallet) GetAccount(prefix string) (*Account, error) {
+	if id, err := types.ParseAccountIdentifier(prefix); err == nil {
+		a := &Account{}
+		if account, ok := w.Accounts[hex.EncodeToString(id.Address[:])]; ok {
+			*a = *account
+		}
+		a.Address = id.Address
+		a.SubAddress = id.SubAddress
+		return a, nil
+	}
+
 	var seen *Account
 	for addr, account := range w.Accounts {
 		if strings.HasPrefix(string(addr[:]), prefix) {
@@ -9,6 +9,8 @@ import (
 
 	"github.com/gopherjs/gopherjs/js"
 	"github.com/miratronix/jopher"
+	"golang.org/x/crypto/ed25519"
+
 	"github.com/the729/go-libra/client"
 	"github.com/the729/go-libra/types"
 )
@@ -69,13 +71,16 @@ func wrapClientObject(c *client.Client) *js.Object {
 			ExpirationTimestamp int64         `js:"expirationTimestamp"`
 		}
 		jstxn := &jsP2PTxn{Object: txn}
-		rawTxn, _ := client.NewRawP2PTransaction(
+		rawTxn, err := client.NewRawP2PTransaction(
 			jstxn.SenderAddr, jstxn.RecvAddr, jstxn.RecvAuthKeyPrefix,
 			jstxn.SenderSeq,
 			jstxn.AmountMicro, jstxn.MaxGasAmount, jstxn.GasUnitPrice,
 			time.Unix(jstxn.ExpirationTimestamp, 0),
 		)
-		return c.SubmitRawTransaction(context.TODO(), rawTxn, jstxn.SenderPriKey)
+		if err != nil {
+			return 0, err
+		}
+		return c.SubmitRawTransaction(context.TODO(), rawTxn, ed25519.PrivateKey(jstxn.SenderPriKey))
 	})
 	jc.submitP2PTransaction = func(rawTxn *js.Object) *js.Object {
 		return promiseSubmitP2PTransaction(rawTxn)
@@ -165,7 +170,7 @@ func wrapClientObject(c *client.Client) *js.Object {
 		} else {
 			rawTxn.Payload = types.TxnPayloadModule(jstxn.Payload.Module)
 		}
-		return c.SubmitRawTransaction(context.TODO(), rawTxn, jstxn.SenderPriKey)
+		return c.SubmitRawTransaction(context.TODO(), rawTxn, ed25519.PrivateKey(jstxn.SenderPriKey))
 	})
 	jc.submitRawTransaction = func(rawTxn *js.Object) *js.Object {
 		return promiseSubmitRawTransaction(rawTxn)
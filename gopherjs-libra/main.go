@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/gopherjs/gopherjs/js"
 	"github.com/miratronix/jopher"
 
@@ -30,6 +32,8 @@ func main() {
 		"pubkeyToAddress":          client.PubkeyMustToAddress,
 		"pubkeyToAuthKey":          client.PubkeyMustToAuthKey,
 		"inferProgramName":         stdscript.InferProgramName,
+		"encodeAccountIdentifier":  encodeAccountIdentifier,
+		"parseAccountIdentifier":   parseAccountIdentifier,
 	})
 	jsTypeOf = js.Global.Call("eval", `(function(x){return typeof(x);})`)
 }
@@ -52,3 +56,32 @@ func newClient(server string, state *js.Object) *js.Object {
 	}
 	return wrapClientObject(c)
 }
+
+func encodeAccountIdentifier(prefix string, address, subAddress []byte) string {
+	if len(address) != types.AccountAddressLength {
+		panic(fmt.Sprintf("wrong address length %d", len(address)))
+	}
+	if len(subAddress) != 0 && len(subAddress) != types.SubAddressLength {
+		panic(fmt.Sprintf("wrong subaddress length %d", len(subAddress)))
+	}
+	id := &types.AccountIdentifier{Prefix: prefix}
+	copy(id.Address[:], address)
+	copy(id.SubAddress[:], subAddress)
+	s, err := id.Encode()
+	jopher.ThrowOnError(err)
+	return s
+}
+
+func parseAccountIdentifier(s string) map[string]interface{} {
+	id, err := types.ParseAccountIdentifier(s)
+	jopher.ThrowOnError(err)
+	var subAddress []byte
+	if !id.SubAddress.IsZero() {
+		subAddress = id.SubAddress[:]
+	}
+	return map[string]interface{}{
+		"prefix":     id.Prefix,
+		"address":    id.Address[:],
+		"subAddress": subAddress,
+	}
+}
@@ -0,0 +1,31 @@
+package fileutil_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/internal/fileutil"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.json")
+	for _, data := range []string{"first", "second"} {
+		require.NoError(t, fileutil.WriteFileAtomic(file, []byte(data)))
+		got, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(got))
+	}
+
+	// no temporary file is left
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "data.json", files[0].Name())
+
+	assert.Error(t, fileutil.WriteFileAtomic(filepath.Join(dir, "missing", "data.json"), nil))
+}
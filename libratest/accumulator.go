@@ -0,0 +1,153 @@
+package libratest
+
+import (
+	"hash"
+	"math/bits"
+
+	"github.com/the729/go-libra/crypto/sha3libra"
+)
+
+// merkleTree is a Merkle tree accumulator which keeps every frozen node, so that
+// proofs can be generated against any historical number of leaves.
+type merkleTree struct {
+	hasher hash.Hash
+
+	// levels[0] are leaf hashes, levels[h][i] is the root of the full subtree
+	// covering leaves [i<<h, (i+1)<<h).
+	levels [][]HashValue
+}
+
+func newMerkleTree(hasher hash.Hash) *merkleTree {
+	return &merkleTree{hasher: hasher}
+}
+
+func (t *merkleTree) numLeaves() uint64 {
+	if len(t.levels) == 0 {
+		return 0
+	}
+	return uint64(len(t.levels[0]))
+}
+
+func (t *merkleTree) hashPair(left, right HashValue) HashValue {
+	t.hasher.Reset()
+	t.hasher.Write(left)
+	t.hasher.Write(right)
+	return t.hasher.Sum([]byte{})
+}
+
+// append appends a leaf and freezes all the full subtrees it completes.
+func (t *merkleTree) append(leaf HashValue) {
+	if len(t.levels) == 0 {
+		t.levels = append(t.levels, nil)
+	}
+	t.levels[0] = append(t.levels[0], leaf)
+	for h := 0; len(t.levels[h])%2 == 0; h++ {
+		if h+1 == len(t.levels) {
+			t.levels = append(t.levels, nil)
+		}
+		n := len(t.levels[h])
+		t.levels[h+1] = append(t.levels[h+1], t.hashPair(t.levels[h][n-2], t.levels[h][n-1]))
+	}
+}
+
+// clone returns a copy of the tree which can be appended independently.
+func (t *merkleTree) clone(hasher hash.Hash) *merkleTree {
+	out := &merkleTree{hasher: hasher}
+	for _, level := range t.levels {
+		out.levels = append(out.levels, append([]HashValue(nil), level...))
+	}
+	return out
+}
+
+// nodeHash returns the hash of node (h, idx) in a tree of numLeaves leaves.
+// It returns nil if the node is a placeholder.
+func (t *merkleTree) nodeHash(h uint, idx, numLeaves uint64) HashValue {
+	first := idx << h
+	if first >= numLeaves {
+		return nil
+	}
+	if first+(uint64(1)<<h) <= numLeaves {
+		return t.levels[h][idx]
+	}
+	left := t.nodeHash(h-1, idx*2, numLeaves)
+	right := t.nodeHash(h-1, idx*2+1, numLeaves)
+	return t.hashPair(orPlaceholder(left), orPlaceholder(right))
+}
+
+// rootHash returns the root hash of the tree with first numLeaves leaves.
+func (t *merkleTree) rootHash(numLeaves uint64) HashValue {
+	if numLeaves == 0 {
+		return sha3libra.AccumulatorPlaceholderHash
+	}
+	return t.nodeHash(treeDepth(numLeaves), 0, numLeaves)
+}
+
+// proof returns the siblings of a leaf, from the leaf up to the root. Placeholder
+// siblings are represented by empty byte slices.
+func (t *merkleTree) proof(leafIdx, numLeaves uint64) [][]byte {
+	depth := treeDepth(numLeaves)
+	siblings := make([][]byte, 0, depth)
+	for h := uint(0); h < depth; h++ {
+		siblings = append(siblings, t.nodeHash(h, (leafIdx>>h)^1, numLeaves))
+	}
+	return siblings
+}
+
+// rangeProof returns the left siblings of the first leaf and the right siblings
+// of the last leaf of a consecutive range of leaves.
+func (t *merkleTree) rangeProof(firstIdx, count, numLeaves uint64) (left, right [][]byte) {
+	if count == 0 {
+		return nil, nil
+	}
+	lastIdx := firstIdx + count - 1
+	for h := uint(0); h < treeDepth(numLeaves); h++ {
+		first, last := firstIdx>>h, lastIdx>>h
+		if first&1 == 1 {
+			left = append(left, t.nodeHash(h, first-1, numLeaves))
+		}
+		if last&1 == 0 {
+			right = append(right, t.nodeHash(h, last+1, numLeaves))
+		}
+	}
+	return left, right
+}
+
+// consistencyProof returns the frozen subtree roots which extend an accumulator
+// of oldNumLeaves leaves to newNumLeaves leaves.
+func (t *merkleTree) consistencyProof(oldNumLeaves, newNumLeaves uint64) [][]byte {
+	var subtrees [][]byte
+	pos, remaining := oldNumLeaves, newNumLeaves-oldNumLeaves
+	for pos != 0 {
+		h := uint(bits.TrailingZeros64(pos))
+		size := uint64(1) << h
+		if size > remaining {
+			break
+		}
+		subtrees = append(subtrees, t.levels[h][pos>>h])
+		pos += size
+		remaining -= size
+	}
+	for remaining != 0 {
+		h := uint(63 - bits.LeadingZeros64(remaining))
+		size := uint64(1) << h
+		subtrees = append(subtrees, t.levels[h][pos>>h])
+		pos += size
+		remaining -= size
+	}
+	return subtrees
+}
+
+// treeDepth returns the number of levels above leaves in a tree of numLeaves leaves.
+func treeDepth(numLeaves uint64) uint {
+	if numLeaves <= 1 {
+		return 0
+	}
+	return uint(64 - bits.LeadingZeros64(numLeaves-1))
+}
+
+func orPlaceholder(h HashValue) HashValue {
+	if h == nil {
+		return sha3libra.AccumulatorPlaceholderHash
+	}
+	return h
+}
@@ -0,0 +1,631 @@
+/*
+Package libratest implements an in-memory Libra ledger and a mock AdmissionControl
+server on top of it, for hermetic tests.
+
+The ledger keeps all transactions, transaction infos, account states and events,
+and signs every new ledger info with locally generated validator keys. The server
+answers UpdateToLatestLedger with genuine proofs, i.e. validator signatures,
+accumulator proofs, sparse Merkle proofs and validator change proofs, so that
+it can be verified by the client package like a real Libra node.
+
+A typical test looks like:
+
+	l := libratest.NewLedger(4)
+	l.CreateAccount(addr, authKey, 1000000)
+	s := libratest.NewServer(l)
+	serverAddr, err := s.Start()
+	defer s.Stop()
+	c, err := client.New(serverAddr, l.Waypoint())
+
+Helpers such as NewAccount, Transfer and StartServer shorten common steps of tests:
+
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	s, c := libratest.StartServer(t, l)
+	libratest.Transfer(t, c, alice, bob, 0, 10)
+*/
+package libratest
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/the729/go-libra/crypto/sha3libra"
+	"github.com/the729/go-libra/language/stdscript"
+	"github.com/the729/go-libra/types"
+	"github.com/the729/go-libra/types/proof"
+	"github.com/the729/lcs"
+)
+
+// HashValue is equivalent to sha3libra.HashValue, which is []byte
+type HashValue = sha3libra.HashValue
+
+const (
+	receivedEventKeySalt = 0
+	sentEventKeySalt     = 1
+)
+
+// Validator is a locally generated validator with its consensus private key.
+type Validator struct {
+	Address     types.AccountAddress
+	PrivateKey  ed25519.PrivateKey
+	VotingPower uint64
+}
+
+// TransactionToCommit is a transaction together with its execution output.
+type TransactionToCommit struct {
+	// Transaction is the user transaction, writeset or block metadata.
+	Transaction *types.Transaction
+
+	// AccountStates are the new account blobs written by this transaction.
+	AccountStates map[types.AccountAddress]types.RawAccountBlob
+
+	// Events are the output events. Event sequence numbers must continue from
+	// the existing events with the same key.
+	Events []*types.ContractEvent
+
+	GasUsed     uint64
+	MajorStatus types.VMStatusCode
+}
+
+type committedTxn struct {
+	rawTxn    []byte
+	info      *types.TransactionInfo
+	events    types.EventList
+	signedTxn *types.SignedTransaction
+}
+
+type eventLocation struct {
+	version uint64
+	index   uint64
+}
+
+// Ledger is an in-memory Libra ledger.
+type Ledger struct {
+	// Clock returns the current time, which is used as ledger info timestamps.
+	// Ledger timestamps are always strictly increasing, regardless of Clock.
+	Clock func() time.Time
+
+	mu            sync.RWMutex
+	epoch         uint64
+	round         uint64
+	validators    []*Validator
+	txns          []*committedTxn
+	accumulator   *merkleTree
+	accounts      map[types.AccountAddress]types.RawAccountBlob
+	accountTxns   map[types.AccountAddress][]uint64
+	events        map[string][]eventLocation
+	epochChanges  []*types.LedgerInfoWithSignatures
+	latest        *types.LedgerInfoWithSignatures
+	lastTimestamp uint64
+}
+
+// NewLedger creates a new ledger with a genesis transaction, and a given
+// number of newly generated validators.
+//
+// The genesis ledger info ends epoch 0, and the validators sign from epoch 1.
+// A block metadata transaction is committed at version 1 so that the latest
+// ledger info is always signed.
+func NewLedger(numValidators int) *Ledger {
+	l := &Ledger{
+		Clock:       time.Now,
+		accumulator: newMerkleTree(sha3libra.NewTransactionAccumulator()),
+		accounts:    make(map[types.AccountAddress]types.RawAccountBlob),
+		accountTxns: make(map[types.AccountAddress][]uint64),
+		events:      make(map[string][]eventLocation),
+	}
+	genesis := &TransactionToCommit{
+		Transaction: &types.Transaction{Transaction: types.WriteSet(nil)},
+		MajorStatus: types.EXECUTED,
+	}
+	if err := l.commit([]*TransactionToCommit{genesis}, GenerateValidators(numValidators)); err != nil {
+		panic(err)
+	}
+	if err := l.commit([]*TransactionToCommit{l.blockMetadata()}, nil); err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// GenerateValidators generates a list of validators with random keys and
+// voting power of 1.
+func GenerateValidators(n int) []*Validator {
+	vs := make([]*Validator, 0, n)
+	for i := 0; i < n; i++ {
+		pubkey, prikey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+		v := &Validator{PrivateKey: prikey, VotingPower: 1}
+		copy(v.Address[:], pubkey)
+		vs = append(vs, v)
+	}
+	return vs
+}
+
+// Waypoint returns the text representation of the genesis waypoint.
+func (l *Ledger) Waypoint() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	wp := (&types.Waypoint{}).FromLedgerInfo(l.epochChanges[0].Value.(*types.LedgerInfoWithSignaturesV0).LedgerInfo)
+	b, _ := wp.MarshalText()
+	return string(b)
+}
+
+// EpochWaypoint returns the text representation of the waypoint at the end of an epoch.
+// The waypoint of epoch 0 is the genesis waypoint. It returns an empty string if the epoch
+// has not ended yet.
+func (l *Ledger) EpochWaypoint(epoch uint64) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if epoch >= uint64(len(l.epochChanges)) {
+		return ""
+	}
+	wp := (&types.Waypoint{}).FromLedgerInfo(l.epochChanges[epoch].Value.(*types.LedgerInfoWithSignaturesV0).LedgerInfo)
+	b, _ := wp.MarshalText()
+	return string(b)
+}
+
+// Version returns the latest version of the ledger.
+func (l *Ledger) Version() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return uint64(len(l.txns)) - 1
+}
+
+// Epoch returns the current epoch of the ledger.
+func (l *Ledger) Epoch() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.epoch
+}
+
+// Validators returns the validators of current epoch.
+func (l *Ledger) Validators() []*Validator {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]*Validator(nil), l.validators...)
+}
+
+// LatestLedgerInfo returns a copy of the latest signed ledger info.
+func (l *Ledger) LatestLedgerInfo() *types.LedgerInfoWithSignatures {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return cloneLedgerInfoWithSigs(l.latest)
+}
+
+// Commit commits a block of transactions, and signs a new ledger info at the
+// new latest version.
+func (l *Ledger) Commit(txns ...*TransactionToCommit) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.commit(txns, nil)
+}
+
+// Reconfigure ends current epoch with a ledger info carrying the given validator
+// set, and starts a new epoch signed by them. A block metadata transaction is
+// committed in the new epoch, so that the latest ledger info is never an epoch
+// ending one.
+//
+// If validators is nil, a new set with the same number of validators is generated.
+func (l *Ledger) Reconfigure(validators []*Validator) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if validators == nil {
+		validators = GenerateValidators(len(l.validators))
+	}
+	if err := l.commit([]*TransactionToCommit{l.blockMetadata()}, validators); err != nil {
+		return err
+	}
+	return l.commit([]*TransactionToCommit{l.blockMetadata()}, nil)
+}
+
+// Fork returns a deep copy of the ledger, with the same validators. The two
+// ledgers can then be extended independently, e.g. to test hard fork detection.
+func (l *Ledger) Fork() *Ledger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := &Ledger{
+		Clock:         l.Clock,
+		epoch:         l.epoch,
+		round:         l.round,
+		validators:    append([]*Validator(nil), l.validators...),
+		txns:          append([]*committedTxn(nil), l.txns...),
+		accumulator:   l.accumulator.clone(sha3libra.NewTransactionAccumulator()),
+		accounts:      make(map[types.AccountAddress]types.RawAccountBlob),
+		accountTxns:   make(map[types.AccountAddress][]uint64),
+		events:        make(map[string][]eventLocation),
+		epochChanges:  append([]*types.LedgerInfoWithSignatures(nil), l.epochChanges...),
+		latest:        l.latest,
+		lastTimestamp: l.lastTimestamp,
+	}
+	for k, v := range l.accounts {
+		out.accounts[k] = v
+	}
+	for k, v := range l.accountTxns {
+		out.accountTxns[k] = append([]uint64(nil), v...)
+	}
+	for k, v := range l.events {
+		out.events[k] = append([]eventLocation(nil), v...)
+	}
+	return out
+}
+
+func (l *Ledger) commit(txns []*TransactionToCommit, nextValidators []*Validator) error {
+	if len(txns) == 0 {
+		return errors.New("empty block")
+	}
+	for _, txn := range txns {
+		if err := l.commitOne(txn); err != nil {
+			return err
+		}
+	}
+
+	numLeaves := uint64(len(l.txns))
+	l.round++
+	timestamp := uint64(l.Clock().UnixNano() / 1000)
+	if timestamp <= l.lastTimestamp {
+		timestamp = l.lastTimestamp + 1
+	}
+	l.lastTimestamp = timestamp
+	li := &types.LedgerInfo{
+		Epoch:                      l.epoch,
+		Round:                      l.round,
+		ConsensusBlockID:           sha3libra.NewBlock().Sum(uint64ToBytes(l.round)),
+		TransactionAccumulatorHash: l.accumulator.rootHash(numLeaves),
+		Version:                    numLeaves - 1,
+		TimestampUsec:              timestamp,
+		ConsensusDataHash:          make([]byte, sha3libra.HashSize),
+	}
+	if nextValidators != nil {
+		li.NextValidatorSet = toValidatorSet(nextValidators)
+	}
+	li0 := &types.LedgerInfoWithSignatures{
+		Value: &types.LedgerInfoWithSignaturesV0{
+			LedgerInfo: li,
+			Sigs:       l.sign(li),
+		},
+	}
+	l.latest = li0
+	if nextValidators != nil {
+		l.epochChanges = append(l.epochChanges, li0)
+		l.epoch++
+		l.validators = nextValidators
+	}
+	return nil
+}
+
+func (l *Ledger) commitOne(txn *TransactionToCommit) error {
+	version := uint64(len(l.txns))
+	raw, err := lcs.Marshal(txn.Transaction)
+	if err != nil {
+		return fmt.Errorf("marshal transaction error: %v", err)
+	}
+	for _, ev := range txn.Events {
+		ev0 := ev.Value.(*types.ContractEventV0)
+		if uint64(len(l.events[string(ev0.Key)])) != ev0.SequenceNumber {
+			return fmt.Errorf("unexpected event sequence number %d", ev0.SequenceNumber)
+		}
+	}
+	for addr, blob := range txn.AccountStates {
+		l.accounts[addr] = blob
+	}
+	txnHasher := sha3libra.NewTransaction()
+	txnHasher.Write(raw)
+	events := types.EventList(txn.Events)
+	info := &types.TransactionInfo{
+		TransactionHash: txnHasher.Sum([]byte{}),
+		StateRootHash:   l.stateTree().rootHash(),
+		EventRootHash:   events.Hash(),
+		GasUsed:         txn.GasUsed,
+		MajorStatus:     txn.MajorStatus,
+	}
+	ct := &committedTxn{rawTxn: raw, info: info, events: events.Clone()}
+	if stxn, ok := txn.Transaction.Transaction.(*types.SignedTransaction); ok {
+		ct.signedTxn = stxn
+		l.accountTxns[stxn.RawTxn.Sender] = append(l.accountTxns[stxn.RawTxn.Sender], version)
+	}
+	for idx, ev := range txn.Events {
+		key := string(ev.Value.(*types.ContractEventV0).Key)
+		l.events[key] = append(l.events[key], eventLocation{version, uint64(idx)})
+	}
+	l.txns = append(l.txns, ct)
+	l.accumulator.append(info.Hash())
+	return nil
+}
+
+func (l *Ledger) sign(li *types.LedgerInfo) map[types.AccountAddress]HashValue {
+	sigs := make(map[types.AccountAddress]HashValue)
+	if li.Epoch == 0 {
+		// genesis is trusted by waypoint, not by signatures
+		return sigs
+	}
+	hash := li.Hash()
+	for _, v := range l.validators {
+		sigs[v.Address] = ed25519.Sign(v.PrivateKey, hash)
+	}
+	return sigs
+}
+
+func (l *Ledger) blockMetadata() *TransactionToCommit {
+	bm := &types.BlockMetaData{
+		ID:            sha3libra.NewBlock().Sum(uint64ToBytes(l.round + 1)),
+		Round:         l.round + 1,
+		TimestampUSec: l.lastTimestamp + 1,
+	}
+	if len(l.validators) > 0 {
+		bm.Proposer = l.validators[0].Address
+	}
+	return &TransactionToCommit{
+		Transaction: &types.Transaction{Transaction: bm},
+		MajorStatus: types.EXECUTED,
+	}
+}
+
+func (l *Ledger) stateTree() *sparseMerkleTree {
+	leaves := make([]*proof.LeafNode, 0, len(l.accounts))
+	for addr, blob := range l.accounts {
+		leaves = append(leaves, &proof.LeafNode{Key: addr.Hash(), ValueHash: blob.Hash()})
+	}
+	return newSparseMerkleTree(leaves)
+}
+
+// epochOfVersion returns the epoch which the transaction at version belongs to.
+func (l *Ledger) epochOfVersion(version uint64) uint64 {
+	epoch := uint64(0)
+	for _, li := range l.epochChanges {
+		if li.Value.(*types.LedgerInfoWithSignaturesV0).Version < version {
+			epoch++
+		}
+	}
+	return epoch
+}
+
+// GetAccount returns the account resource and balance resource of an account.
+// It returns nil resources if the account does not exist.
+func (l *Ledger) GetAccount(addr types.AccountAddress) (*types.AccountResource, *types.BalanceResource, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.getAccount(addr)
+}
+
+func (l *Ledger) getAccount(addr types.AccountAddress) (*types.AccountResource, *types.BalanceResource, error) {
+	raw, ok := l.accounts[addr]
+	if !ok {
+		return nil, nil, nil
+	}
+	blob := &types.AccountBlob{}
+	if err := blob.ParseToMap(raw); err != nil {
+		return nil, nil, err
+	}
+	ar, err := blob.GetLibraAccountResource()
+	if err != nil {
+		return nil, nil, err
+	}
+	br, err := blob.GetLibraBalanceResource()
+	if err != nil {
+		return nil, nil, err
+	}
+	return ar, br, nil
+}
+
+// CreateAccount commits a writeset transaction which creates an account with
+// given authentication key and balance.
+func (l *Ledger) CreateAccount(addr types.AccountAddress, authKey []byte, balance uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.accounts[addr]; ok {
+		return errors.New("account already exists")
+	}
+	ar := &types.AccountResource{
+		AuthenticationKey: authKey,
+		ReceivedEvents:    &types.EventHandle{Key: EventKey(addr, receivedEventKeySalt)},
+		SentEvents:        &types.EventHandle{Key: EventKey(addr, sentEventKeySalt)},
+		EventGenerator:    2,
+	}
+	br := &types.BalanceResource{Coin: balance}
+	arBytes, _ := lcs.Marshal(ar)
+	brBytes, _ := lcs.Marshal(br)
+	ws := types.WriteSet{
+		{AccessPath: &types.AccessPath{Address: addr, Path: types.AccountResourcePath()}, WriteOp: types.WriteOpValue(arBytes)},
+		{AccessPath: &types.AccessPath{Address: addr, Path: types.BalanceResourcePath()}, WriteOp: types.WriteOpValue(brBytes)},
+	}
+	return l.commit([]*TransactionToCommit{{
+		Transaction:   &types.Transaction{Transaction: ws},
+		AccountStates: map[types.AccountAddress]types.RawAccountBlob{addr: AccountBlob(ar, br)},
+		MajorStatus:   types.EXECUTED,
+	}}, nil)
+}
+
+// AccountBlob builds a raw account blob from an account resource and a balance resource.
+func AccountBlob(ar *types.AccountResource, br *types.BalanceResource) types.RawAccountBlob {
+	arBytes, err := lcs.Marshal(ar)
+	if err != nil {
+		panic(err)
+	}
+	brBytes, err := lcs.Marshal(br)
+	if err != nil {
+		panic(err)
+	}
+	raw, err := lcs.Marshal(&types.AccountBlob{Map: map[string][]byte{
+		string(types.AccountResourcePath()): arBytes,
+		string(types.BalanceResourcePath()): brBytes,
+	}})
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// EventKey builds an event key from an account address and a salt.
+func EventKey(addr types.AccountAddress, salt uint64) types.EventKey {
+	return append(uint64ToBytes(salt), addr[:]...)
+}
+
+// Execute executes a signed transaction and commits it in a new block.
+// It returns the version of the transaction.
+//
+// Only peer-to-peer transfer scripts are actually executed. Other transactions
+// only bump the sequence number of the sender.
+func (l *Ledger) Execute(stxn *types.SignedTransaction) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	txn, err := l.execute(stxn)
+	if err != nil {
+		return 0, err
+	}
+	if err := l.commit([]*TransactionToCommit{txn}, nil); err != nil {
+		return 0, err
+	}
+	return uint64(len(l.txns)) - 1, nil
+}
+
+// ValidateTransaction checks the signature, sender, sequence number and
+// expiration time of a signed transaction against the latest state.
+// It returns the VM status code of the validation and the current sequence
+// number of the sender.
+func (l *Ledger) ValidateTransaction(stxn *types.SignedTransaction) (types.VMStatusCode, uint64) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.validate(stxn)
+}
+
+func (l *Ledger) validate(stxn *types.SignedTransaction) (types.VMStatusCode, uint64) {
+	if err := stxn.VerifySignature(); err != nil {
+		return types.INVALID_SIGNATURE, 0
+	}
+	ar, _, err := l.getAccount(stxn.RawTxn.Sender)
+	if err != nil || ar == nil {
+		return types.SENDING_ACCOUNT_DOES_NOT_EXIST, 0
+	}
+	if stxn.RawTxn.SequenceNumber < ar.SequenceNumber {
+		return types.SEQUENCE_NUMBER_TOO_OLD, ar.SequenceNumber
+	}
+	if stxn.RawTxn.ExpirationTime*1000000 <= l.lastTimestamp {
+		return types.TRANSACTION_EXPIRED, ar.SequenceNumber
+	}
+	return types.EXECUTED, ar.SequenceNumber
+}
+
+func (l *Ledger) execute(stxn *types.SignedTransaction) (*TransactionToCommit, error) {
+	status, seq := l.validate(stxn)
+	if status != types.EXECUTED {
+		return nil, fmt.Errorf("transaction validation failed: %v", status)
+	}
+	if stxn.RawTxn.SequenceNumber != seq {
+		return nil, fmt.Errorf("transaction validation failed: %v", types.SEQUENCE_NUMBER_TOO_NEW)
+	}
+	sender := stxn.RawTxn.Sender
+	ar, br, _ := l.getAccount(sender)
+	ar.SequenceNumber++
+	out := &TransactionToCommit{
+		Transaction:   &types.Transaction{Transaction: stxn},
+		AccountStates: make(map[types.AccountAddress]types.RawAccountBlob),
+		GasUsed:       0,
+		MajorStatus:   types.EXECUTED,
+	}
+
+	receiver, authKeyPrefix, amount, ok := parseP2P(stxn.RawTxn)
+	if !ok {
+		out.AccountStates[sender] = AccountBlob(ar, br)
+		return out, nil
+	}
+	if br.Coin < amount {
+		out.MajorStatus = types.ABORTED
+		out.AccountStates[sender] = AccountBlob(ar, br)
+		return out, nil
+	}
+
+	rar, rbr, _ := l.getAccount(receiver)
+	if receiver == sender {
+		rar, rbr = ar, br
+	}
+	if rar == nil {
+		rar = &types.AccountResource{
+			AuthenticationKey: append(append([]byte{}, authKeyPrefix...), receiver[:]...),
+			ReceivedEvents:    &types.EventHandle{Key: EventKey(receiver, receivedEventKeySalt)},
+			SentEvents:        &types.EventHandle{Key: EventKey(receiver, sentEventKeySalt)},
+			EventGenerator:    2,
+		}
+		rbr = &types.BalanceResource{}
+	}
+	br.Coin -= amount
+	rbr.Coin += amount
+
+	sentData, _ := lcs.Marshal(&stdscript.PaymentEvent{Amount: amount, Address: receiver})
+	recvData, _ := lcs.Marshal(&stdscript.PaymentEvent{Amount: amount, Address: sender})
+	out.Events = []*types.ContractEvent{
+		newEvent(ar.SentEvents, "SentPaymentEvent", sentData),
+		newEvent(rar.ReceivedEvents, "ReceivedPaymentEvent", recvData),
+	}
+	out.AccountStates[sender] = AccountBlob(ar, br)
+	out.AccountStates[receiver] = AccountBlob(rar, rbr)
+	return out, nil
+}
+
+func newEvent(handle *types.EventHandle, name string, data []byte) *types.ContractEvent {
+	ev := &types.ContractEvent{Value: &types.ContractEventV0{
+		Key:            handle.Key,
+		SequenceNumber: handle.Count,
+		TypeTag:        &types.StructTag{Module: "LibraAccount", Name: name},
+		Data:           data,
+	}}
+	handle.Count++
+	return ev
+}
+
+func parseP2P(txn *types.RawTransaction) (types.AccountAddress, []byte, uint64, bool) {
+	script, ok := txn.Payload.(*types.TxnPayloadScript)
+	if !ok || stdscript.InferProgramName(script.Code) != "peer_to_peer_transfer" || len(script.Args) != 3 {
+		return types.AccountAddress{}, nil, 0, false
+	}
+	receiver, ok1 := script.Args[0].(types.TxnArgAddress)
+	prefix, ok2 := script.Args[1].(types.TxnArgBytes)
+	amount, ok3 := script.Args[2].(types.TxnArgU64)
+	if !ok1 || !ok2 || !ok3 {
+		return types.AccountAddress{}, nil, 0, false
+	}
+	return types.AccountAddress(receiver), []byte(prefix), uint64(amount), true
+}
+
+func toValidatorSet(validators []*Validator) *types.ValidatorSet {
+	vs := &types.ValidatorSet{Scheme: types.SchemeED25519{}}
+	for _, v := range validators {
+		pubkey := v.PrivateKey.Public().(ed25519.PublicKey)
+		vs.Payload = append(vs.Payload, &types.ValidatorInfo{
+			AccountAddress:        v.Address,
+			ConsensusPubkey:       []byte(pubkey),
+			ConsensusVotingPower:  v.VotingPower,
+			NetworkSigningPubkey:  []byte(pubkey),
+			NetworkIdentityPubkey: []byte(pubkey),
+		})
+	}
+	return vs
+}
+
+func cloneLedgerInfoWithSigs(li *types.LedgerInfoWithSignatures) *types.LedgerInfoWithSignatures {
+	li0 := li.Value.(*types.LedgerInfoWithSignaturesV0)
+	sigs := make(map[types.AccountAddress]HashValue)
+	for k, v := range li0.Sigs {
+		sigs[k] = append([]byte{}, v...)
+	}
+	return &types.LedgerInfoWithSignatures{
+		Value: &types.LedgerInfoWithSignaturesV0{
+			LedgerInfo: li0.LedgerInfo.Clone(),
+			Sigs:       sigs,
+		},
+	}
+}
+
+func uint64ToBytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
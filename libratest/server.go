@@ -0,0 +1,493 @@
+// +build !js
+
+package libratest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/the729/go-libra/crypto/sha3libra"
+	"github.com/the729/go-libra/generated/pbac"
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/types"
+	"github.com/the729/lcs"
+)
+
+const maxTransactionsPerRequest = 1000
+
+// Server is a mock AdmissionControl server backed by a Ledger.
+type Server struct {
+	pbac.UnimplementedAdmissionControlServer
+
+	// TamperResponse, if not nil, is called on every UpdateToLatestLedgerResponse
+	// before it is sent, so that tests can simulate a malicious node.
+	TamperResponse func(resp *pbtypes.UpdateToLatestLedgerResponse)
+
+	// HoldTransactions keeps accepted transactions in mempool, until Flush is called.
+	// Otherwise accepted transactions are executed immediately.
+	HoldTransactions bool
+
+	// RejectTransactions, if not zero, makes mempool reject all submitted transactions
+	// with the status code, e.g. types.MempoolIsFull.
+	RejectTransactions types.MempoolStatusCode
+
+	// MaxEpochChanges, if not zero, limits the number of ledger infos in a validator
+	// change proof. If there are more epoch changes, More is set in the proof.
+	MaxEpochChanges int
+
+	ledger *Ledger
+
+	mu      sync.Mutex
+	mempool map[types.AccountAddress]map[uint64]*types.SignedTransaction
+
+	grpcServer *grpc.Server
+}
+
+// NewServer creates a new server backed by the ledger.
+func NewServer(l *Ledger) *Server {
+	return &Server{
+		ledger:  l,
+		mempool: make(map[types.AccountAddress]map[uint64]*types.SignedTransaction),
+	}
+}
+
+// Ledger returns the underlying ledger.
+func (s *Server) Ledger() *Ledger {
+	return s.ledger
+}
+
+// Start starts serving gRPC on a random local port, and returns the server address.
+func (s *Server) Start() (string, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("listen error: %v", err)
+	}
+	s.grpcServer = grpc.NewServer()
+	pbac.RegisterAdmissionControlServer(s.grpcServer, s)
+	go s.grpcServer.Serve(lis)
+	return lis.Addr().String(), nil
+}
+
+// Stop stops the gRPC server.
+func (s *Server) Stop() {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+}
+
+// Flush executes all executable transactions in mempool, and drops expired ones.
+func (s *Server) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.executeReady()
+}
+
+// SubmitTransaction implements pbac.AdmissionControlServer.
+func (s *Server) SubmitTransaction(ctx context.Context, req *pbac.SubmitTransactionRequest) (*pbac.SubmitTransactionResponse, error) {
+	if req.Transaction == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil transaction")
+	}
+	stxn := &types.SignedTransaction{}
+	if err := lcs.Unmarshal(req.Transaction.TxnBytes, stxn); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unmarshal signed transaction error: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vmStatus, _ := s.ledger.ValidateTransaction(stxn)
+	if vmStatus != types.EXECUTED {
+		return &pbac.SubmitTransactionResponse{
+			Status: &pbac.SubmitTransactionResponse_VmStatus{
+				VmStatus: &pbtypes.VMStatus{MajorStatus: uint64(vmStatus)},
+			},
+		}, nil
+	}
+	if s.RejectTransactions != types.MempoolAccepted {
+		return &pbac.SubmitTransactionResponse{
+			Status: &pbac.SubmitTransactionResponse_MempoolStatus{
+				MempoolStatus: &pbtypes.MempoolStatus{
+					Code:    uint64(s.RejectTransactions),
+					Message: "transaction rejected",
+				},
+			},
+		}, nil
+	}
+	pending := s.mempool[stxn.RawTxn.Sender]
+	if pending == nil {
+		pending = make(map[uint64]*types.SignedTransaction)
+		s.mempool[stxn.RawTxn.Sender] = pending
+	}
+	if _, ok := pending[stxn.RawTxn.SequenceNumber]; ok {
+		return &pbac.SubmitTransactionResponse{
+			Status: &pbac.SubmitTransactionResponse_MempoolStatus{
+				MempoolStatus: &pbtypes.MempoolStatus{
+					Code:    uint64(types.MempoolInvalidUpdate),
+					Message: "transaction already in mempool",
+				},
+			},
+		}, nil
+	}
+	pending[stxn.RawTxn.SequenceNumber] = stxn
+
+	if !s.HoldTransactions {
+		if err := s.executeReady(); err != nil {
+			return nil, status.Errorf(codes.Internal, "execute transactions error: %v", err)
+		}
+	}
+	return &pbac.SubmitTransactionResponse{
+		Status: &pbac.SubmitTransactionResponse_AcStatus{
+			AcStatus: &pbac.AdmissionControlStatus{Code: pbac.AdmissionControlStatusCode_Accepted},
+		},
+	}, nil
+}
+
+// executeReady executes pending transactions in sequence number order, and
+// drops those which can never be executed.
+func (s *Server) executeReady() error {
+	for sender, pending := range s.mempool {
+		for {
+			executed := false
+			for seq, stxn := range pending {
+				vmStatus, currentSeq := s.ledger.ValidateTransaction(stxn)
+				if vmStatus != types.EXECUTED {
+					delete(pending, seq)
+					continue
+				}
+				if seq != currentSeq {
+					continue
+				}
+				delete(pending, seq)
+				if _, err := s.ledger.Execute(stxn); err != nil {
+					return err
+				}
+				executed = true
+			}
+			if !executed {
+				break
+			}
+		}
+		if len(pending) == 0 {
+			delete(s.mempool, sender)
+		}
+	}
+	return nil
+}
+
+// UpdateToLatestLedger implements pbac.AdmissionControlServer.
+func (s *Server) UpdateToLatestLedger(ctx context.Context, req *pbtypes.UpdateToLatestLedgerRequest) (*pbtypes.UpdateToLatestLedgerResponse, error) {
+	l := s.ledger
+	l.mu.RLock()
+	resp, err := l.updateToLatestLedger(req, s.MaxEpochChanges)
+	l.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if s.TamperResponse != nil {
+		s.TamperResponse(resp)
+	}
+	return resp, nil
+}
+
+func (l *Ledger) updateToLatestLedger(req *pbtypes.UpdateToLatestLedgerRequest, maxEpochChanges int) (*pbtypes.UpdateToLatestLedgerResponse, error) {
+	numLeaves := uint64(len(l.txns))
+	knownNumLeaves := req.ClientKnownVersion + 1
+	if knownNumLeaves == 0 {
+		// The client knows nothing, and will start from genesis.
+		knownNumLeaves = 1
+	}
+	if knownNumLeaves > numLeaves {
+		return nil, status.Errorf(codes.InvalidArgument, "client known version %d > latest version %d", req.ClientKnownVersion, numLeaves-1)
+	}
+
+	resp := &pbtypes.UpdateToLatestLedgerResponse{
+		LedgerInfoWithSigs: ledgerInfoToProto(l.latest),
+		LedgerConsistencyProof: &pbtypes.AccumulatorConsistencyProof{
+			Subtrees: l.accumulator.consistencyProof(knownNumLeaves, numLeaves),
+		},
+		ValidatorChangeProof: &pbtypes.ValidatorChangeProof{},
+	}
+	knownEpoch := uint64(0)
+	if req.ClientKnownVersion != math.MaxUint64 {
+		knownEpoch = l.epochOfVersion(req.ClientKnownVersion)
+	}
+	epochChanges := l.epochChanges[knownEpoch:]
+	if maxEpochChanges > 0 && len(epochChanges) > maxEpochChanges {
+		epochChanges = epochChanges[:maxEpochChanges]
+		resp.ValidatorChangeProof.More = true
+	}
+	for _, li := range epochChanges {
+		resp.ValidatorChangeProof.LedgerInfoWithSigs = append(resp.ValidatorChangeProof.LedgerInfoWithSigs, ledgerInfoToProto(li))
+	}
+
+	for _, item := range req.RequestedItems {
+		var respItem *pbtypes.ResponseItem
+		var err error
+		switch r := item.RequestedItems.(type) {
+		case *pbtypes.RequestItem_GetAccountStateRequest:
+			respItem, err = l.getAccountState(r.GetAccountStateRequest)
+		case *pbtypes.RequestItem_GetAccountTransactionBySequenceNumberRequest:
+			respItem, err = l.getAccountTransaction(r.GetAccountTransactionBySequenceNumberRequest)
+		case *pbtypes.RequestItem_GetEventsByEventAccessPathRequest:
+			respItem, err = l.getEvents(r.GetEventsByEventAccessPathRequest)
+		case *pbtypes.RequestItem_GetTransactionsRequest:
+			respItem, err = l.getTransactions(r.GetTransactionsRequest)
+		default:
+			err = status.Error(codes.InvalidArgument, "unknown request item")
+		}
+		if err != nil {
+			return nil, err
+		}
+		resp.ResponseItems = append(resp.ResponseItems, respItem)
+	}
+	return resp, nil
+}
+
+func (l *Ledger) accountStateWithProof(addr types.AccountAddress) *pbtypes.AccountStateWithProof {
+	version := uint64(len(l.txns)) - 1
+	leaf, siblings := l.stateTree().proof(addr.Hash())
+	smtProof := &pbtypes.SparseMerkleProof{Siblings: siblings}
+	if leaf != nil {
+		smtProof.Leaf = append(append([]byte{}, leaf.Key...), leaf.ValueHash...)
+	}
+	out := &pbtypes.AccountStateWithProof{
+		Version: version,
+		Proof: &pbtypes.AccountStateProof{
+			LedgerInfoToTransactionInfoProof: l.accumulatorProof(version),
+			TransactionInfo:                  txnInfoToProto(l.txns[version].info),
+			TransactionInfoToAccountProof:    smtProof,
+		},
+	}
+	if blob, ok := l.accounts[addr]; ok {
+		out.Blob = &pbtypes.AccountStateBlob{Blob: blob}
+	}
+	return out
+}
+
+func (l *Ledger) getAccountState(req *pbtypes.GetAccountStateRequest) (*pbtypes.ResponseItem, error) {
+	addr, err := toAddress(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	return &pbtypes.ResponseItem{
+		ResponseItems: &pbtypes.ResponseItem_GetAccountStateResponse{
+			GetAccountStateResponse: &pbtypes.GetAccountStateResponse{
+				AccountStateWithProof: l.accountStateWithProof(addr),
+			},
+		},
+	}, nil
+}
+
+func (l *Ledger) getAccountTransaction(req *pbtypes.GetAccountTransactionBySequenceNumberRequest) (*pbtypes.ResponseItem, error) {
+	addr, err := toAddress(req.Account)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pbtypes.GetAccountTransactionBySequenceNumberResponse{}
+	if versions := l.accountTxns[addr]; req.SequenceNumber < uint64(len(versions)) {
+		resp.TransactionWithProof = l.transactionWithProof(versions[req.SequenceNumber], req.FetchEvents)
+	} else {
+		resp.ProofOfCurrentSequenceNumber = l.accountStateWithProof(addr)
+	}
+	return &pbtypes.ResponseItem{
+		ResponseItems: &pbtypes.ResponseItem_GetAccountTransactionBySequenceNumberResponse{
+			GetAccountTransactionBySequenceNumberResponse: resp,
+		},
+	}, nil
+}
+
+func (l *Ledger) transactionWithProof(version uint64, fetchEvents bool) *pbtypes.TransactionWithProof {
+	txn := l.txns[version]
+	out := &pbtypes.TransactionWithProof{
+		Version:     version,
+		Transaction: &pbtypes.Transaction{Transaction: txn.rawTxn},
+		Proof: &pbtypes.TransactionProof{
+			LedgerInfoToTransactionInfoProof: l.accumulatorProof(version),
+			TransactionInfo:                  txnInfoToProto(txn.info),
+		},
+	}
+	if fetchEvents {
+		out.Events = eventsToProto(txn.events)
+	}
+	return out
+}
+
+func (l *Ledger) getEvents(req *pbtypes.GetEventsByEventAccessPathRequest) (*pbtypes.ResponseItem, error) {
+	if req.AccessPath == nil {
+		return nil, status.Error(codes.InvalidArgument, "nil access path")
+	}
+	addr, err := toAddress(req.AccessPath.Address)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pbtypes.GetEventsByEventAccessPathResponse{
+		ProofOfLatestEvent: l.accountStateWithProof(addr),
+	}
+	ar, _, err := l.getAccount(addr)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get account error: %v", err)
+	}
+
+	var key types.EventKey
+	switch string(req.AccessPath.Path) {
+	case string(types.AccountSentEventPath()):
+		if ar != nil {
+			key = ar.SentEvents.Key
+		}
+	case string(types.AccountReceivedEventPath()):
+		if ar != nil {
+			key = ar.ReceivedEvents.Key
+		}
+	default:
+		// events under other paths are not emitted by the test ledger
+	}
+
+	locations := l.events[string(key)]
+	count := uint64(len(locations))
+	start := req.StartEventSeqNum
+	var seqs []uint64
+	if req.Ascending {
+		for seq := start; seq < count && uint64(len(seqs)) < req.Limit; seq++ {
+			seqs = append(seqs, seq)
+		}
+	} else if count > 0 {
+		if start >= count {
+			start = count - 1
+		}
+		for seq := int64(start); seq >= 0 && uint64(len(seqs)) < req.Limit; seq-- {
+			seqs = append(seqs, uint64(seq))
+		}
+	}
+
+	for _, seq := range seqs {
+		loc := locations[seq]
+		txn := l.txns[loc.version]
+		events := newMerkleTree(sha3libra.NewEventAccumulator())
+		for _, ev := range txn.events {
+			events.append(ev.Hash())
+		}
+		resp.EventsWithProof = append(resp.EventsWithProof, &pbtypes.EventWithProof{
+			TransactionVersion: loc.version,
+			EventIndex:         loc.index,
+			Event:              eventToProto(txn.events[loc.index]),
+			Proof: &pbtypes.EventProof{
+				LedgerInfoToTransactionInfoProof: l.accumulatorProof(loc.version),
+				TransactionInfo:                  txnInfoToProto(txn.info),
+				TransactionInfoToEventProof: &pbtypes.AccumulatorProof{
+					Siblings: events.proof(loc.index, events.numLeaves()),
+				},
+			},
+		})
+	}
+	return &pbtypes.ResponseItem{
+		ResponseItems: &pbtypes.ResponseItem_GetEventsByEventAccessPathResponse{
+			GetEventsByEventAccessPathResponse: resp,
+		},
+	}, nil
+}
+
+func (l *Ledger) getTransactions(req *pbtypes.GetTransactionsRequest) (*pbtypes.ResponseItem, error) {
+	if req.Limit > maxTransactionsPerRequest {
+		return nil, status.Errorf(codes.InvalidArgument, "limit %d exceeds %d", req.Limit, maxTransactionsPerRequest)
+	}
+	numLeaves := uint64(len(l.txns))
+	start, limit := req.StartVersion, req.Limit
+	if start >= numLeaves {
+		limit = 0
+	} else if start+limit > numLeaves {
+		limit = numLeaves - start
+	}
+
+	list := &pbtypes.TransactionListWithProof{
+		Proof: &pbtypes.TransactionListProof{
+			LedgerInfoToTransactionInfosProof: &pbtypes.AccumulatorRangeProof{},
+		},
+	}
+	if limit > 0 {
+		list.FirstTransactionVersion = &wrappers.UInt64Value{Value: start}
+		left, right := l.accumulator.rangeProof(start, limit, numLeaves)
+		list.Proof.LedgerInfoToTransactionInfosProof.LeftSiblings = left
+		list.Proof.LedgerInfoToTransactionInfosProof.RightSiblings = right
+		if req.FetchEvents {
+			list.EventsForVersions = &pbtypes.EventsForVersions{}
+		}
+	}
+	for v := start; v < start+limit; v++ {
+		txn := l.txns[v]
+		list.Transactions = append(list.Transactions, &pbtypes.Transaction{Transaction: txn.rawTxn})
+		list.Proof.TransactionInfos = append(list.Proof.TransactionInfos, txnInfoToProto(txn.info))
+		if req.FetchEvents {
+			list.EventsForVersions.EventsForVersion = append(list.EventsForVersions.EventsForVersion, eventsToProto(txn.events))
+		}
+	}
+	return &pbtypes.ResponseItem{
+		ResponseItems: &pbtypes.ResponseItem_GetTransactionsResponse{
+			GetTransactionsResponse: &pbtypes.GetTransactionsResponse{
+				TxnListWithProof: list,
+			},
+		},
+	}, nil
+}
+
+func (l *Ledger) accumulatorProof(version uint64) *pbtypes.AccumulatorProof {
+	return &pbtypes.AccumulatorProof{
+		Siblings: l.accumulator.proof(version, uint64(len(l.txns))),
+	}
+}
+
+func toAddress(b []byte) (types.AccountAddress, error) {
+	var addr types.AccountAddress
+	if len(b) != len(addr) {
+		return addr, status.Error(codes.InvalidArgument, "invalid address length")
+	}
+	copy(addr[:], b)
+	return addr, nil
+}
+
+func ledgerInfoToProto(li *types.LedgerInfoWithSignatures) *pbtypes.LedgerInfoWithSignatures {
+	b, err := lcs.Marshal(li)
+	if err != nil {
+		panic(err)
+	}
+	return &pbtypes.LedgerInfoWithSignatures{Bytes: b}
+}
+
+func txnInfoToProto(info *types.TransactionInfo) *pbtypes.TransactionInfo {
+	return &pbtypes.TransactionInfo{
+		TransactionHash: info.TransactionHash,
+		StateRootHash:   info.StateRootHash,
+		EventRootHash:   info.EventRootHash,
+		GasUsed:         info.GasUsed,
+		MajorStatus:     uint64(info.MajorStatus),
+	}
+}
+
+func eventToProto(ev *types.ContractEvent) *pbtypes.Event {
+	ev0 := ev.Value.(*types.ContractEventV0)
+	typeTag, err := lcs.Marshal(&types.TypeTagWrap{Value: ev0.TypeTag})
+	if err != nil {
+		panic(err)
+	}
+	return &pbtypes.Event{
+		Key:            ev0.Key,
+		SequenceNumber: ev0.SequenceNumber,
+		EventData:      ev0.Data,
+		TypeTag:        typeTag,
+	}
+}
+
+func eventsToProto(events types.EventList) *pbtypes.EventsList {
+	out := &pbtypes.EventsList{}
+	for _, ev := range events {
+		out.Events = append(out.Events, eventToProto(ev))
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package libratest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+)
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	_, c := libratest.StartServer(t, l)
+
+	t.Run("ledger info", func(t *testing.T) {
+		pli, err := c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, l.Version(), pli.GetVersion())
+		assert.Equal(t, uint64(1), pli.GetEpochNum())
+	})
+
+	t.Run("account state", func(t *testing.T) {
+		pas, err := c.QueryAccountState(ctx, alice.Address)
+		require.NoError(t, err)
+		require.False(t, pas.IsNil())
+		_, br, err := pas.GetAccountBlob().GetLibraResources()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1000), br.Coin)
+
+		pas, err = c.QueryAccountState(ctx, types.AccountAddress{0xff})
+		require.NoError(t, err)
+		assert.True(t, pas.IsNil())
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		libratest.Transfer(t, c, alice, bob, 0, 300)
+		libratest.Transfer(t, c, alice, bob, 1, 200)
+
+		seq, err := c.QueryAccountSequenceNumber(ctx, alice.Address)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), seq)
+
+		ptxn, err := c.QueryTransactionByAccountSeq(ctx, alice.Address, 1, true)
+		require.NoError(t, err)
+		assert.True(t, ptxn.GetWithEvents())
+		assert.Len(t, ptxn.GetEvents(), 2)
+
+		ptxn, err = c.QueryTransactionByAccountSeq(ctx, alice.Address, 0, false)
+		require.NoError(t, err)
+		assert.False(t, ptxn.GetWithEvents())
+
+		_, err = c.QueryTransactionByAccountSeq(ctx, alice.Address, 2, false)
+		assert.Error(t, err)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		ap := &types.AccessPath{Address: bob.Address, Path: types.AccountReceivedEventPath()}
+		pel, err := c.QueryEventListByAccessPath(ctx, ap, 0, true, 10)
+		require.NoError(t, err)
+		assert.Len(t, pel.GetEvents(), 2)
+		assert.Equal(t, uint64(2), pel.GetTotalCount())
+
+		pel, err = c.QueryEventListByAccessPath(ctx, ap, ^uint64(0), false, 1)
+		require.NoError(t, err)
+		pevs := pel.GetEvents()
+		require.Len(t, pevs, 1)
+		assert.Equal(t, uint64(1), pevs[0].GetEvent().Value.(*types.ContractEventV0).SequenceNumber)
+	})
+
+	t.Run("transaction range", func(t *testing.T) {
+		numTxns := l.Version() + 1
+		for start := uint64(0); start < numTxns; start++ {
+			for limit := uint64(1); start+limit <= numTxns; limit++ {
+				ptl, err := c.QueryTransactionRange(ctx, start, limit, true)
+				require.NoError(t, err, "start=%d, limit=%d", start, limit)
+				assert.Len(t, ptl.GetTransactions(), int(limit))
+			}
+		}
+	})
+}
+
+func TestEpochChange(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	_, c := libratest.StartServer(t, l)
+
+	_, err := c.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+	waypoint := c.GetLatestWaypoint()
+
+	require.NoError(t, l.Reconfigure(nil))
+	require.NoError(t, l.Reconfigure(libratest.GenerateValidators(7)))
+	pli, err := c.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), pli.GetEpochNum())
+	assert.Equal(t, l.Version(), pli.GetVersion())
+	assert.NotEqual(t, waypoint, c.GetLatestWaypoint())
+}
+
+func TestTamperedResponse(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	s, c := libratest.StartServer(t, l)
+
+	_, err := c.QueryLedgerInfo(ctx)
+	require.NoError(t, err)
+
+	t.Run("ledger info", func(t *testing.T) {
+		s.TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			b := resp.LedgerInfoWithSigs.Bytes
+			b[len(b)-1] ^= 1
+		}
+		defer func() { s.TamperResponse = nil }()
+		_, err := c.QueryLedgerInfo(ctx)
+		assert.Error(t, err)
+	})
+
+	t.Run("account state", func(t *testing.T) {
+		s.TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+			blob := resp.ResponseItems[0].GetGetAccountStateResponse().AccountStateWithProof.Blob
+			blob.Blob[len(blob.Blob)-1] ^= 1
+		}
+		defer func() { s.TamperResponse = nil }()
+		_, err := c.QueryAccountState(ctx, alice.Address)
+		assert.Error(t, err)
+	})
+
+	t.Run("fork", func(t *testing.T) {
+		l2 := l.Fork()
+		require.NoError(t, l.Commit(&libratest.TransactionToCommit{
+			Transaction: &types.Transaction{Transaction: types.WriteSet(nil)},
+			MajorStatus: types.EXECUTED,
+		}))
+		_, err := c.QueryLedgerInfo(ctx)
+		require.NoError(t, err)
+
+		_, c2 := libratest.StartServer(t, l2)
+		require.NoError(t, c2.SetState(c.GetState()))
+		require.NoError(t, l2.Commit(&libratest.TransactionToCommit{
+			Transaction: &types.Transaction{Transaction: &types.BlockMetaData{Round: 100}},
+			MajorStatus: types.EXECUTED,
+		}))
+		_, err = c2.QueryLedgerInfo(ctx)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,89 @@
+package libratest
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/the729/go-libra/crypto/sha3libra"
+	"github.com/the729/go-libra/types/proof"
+)
+
+// sparseMerkleTree is a naive sparse Merkle tree, which recomputes internal
+// nodes on every query. It is good enough for a few thousands of accounts.
+type sparseMerkleTree struct {
+	// leaves are sorted by key
+	leaves []*proof.LeafNode
+}
+
+func newSparseMerkleTree(leaves []*proof.LeafNode) *sparseMerkleTree {
+	sorted := append([]*proof.LeafNode(nil), leaves...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Key, sorted[j].Key) < 0
+	})
+	return &sparseMerkleTree{leaves: sorted}
+}
+
+// rootHash returns the root hash of the whole tree.
+func (t *sparseMerkleTree) rootHash() HashValue {
+	return subtreeHash(t.leaves, 0)
+}
+
+// proof returns the leaf (nil if the subtree is empty) and the siblings from the
+// leaf up to the root, for a given key. Placeholder siblings are represented
+// by empty byte slices.
+func (t *sparseMerkleTree) proof(key HashValue) (*proof.LeafNode, [][]byte) {
+	var siblings [][]byte
+	leaves := t.leaves
+	depth := 0
+	for len(leaves) > 1 {
+		left, right := splitAt(leaves, depth)
+		if keyBit(key, depth) {
+			leaves = right
+			siblings = append(siblings, placeholderToEmpty(subtreeHash(left, depth+1)))
+		} else {
+			leaves = left
+			siblings = append(siblings, placeholderToEmpty(subtreeHash(right, depth+1)))
+		}
+		depth++
+	}
+	for i, j := 0, len(siblings)-1; i < j; i, j = i+1, j-1 {
+		siblings[i], siblings[j] = siblings[j], siblings[i]
+	}
+	if len(leaves) == 0 {
+		return nil, siblings
+	}
+	return leaves[0], siblings
+}
+
+func subtreeHash(leaves []*proof.LeafNode, depth int) HashValue {
+	switch len(leaves) {
+	case 0:
+		return sha3libra.SparseMerklePlaceholderHash
+	case 1:
+		return leaves[0].Hash()
+	}
+	left, right := splitAt(leaves, depth)
+	hasher := sha3libra.NewSparseMerkleInternal()
+	hasher.Write(subtreeHash(left, depth+1))
+	hasher.Write(subtreeHash(right, depth+1))
+	return hasher.Sum([]byte{})
+}
+
+// splitAt splits sorted leaves by the bit at depth of their keys.
+func splitAt(leaves []*proof.LeafNode, depth int) (left, right []*proof.LeafNode) {
+	idx := sort.Search(len(leaves), func(i int) bool {
+		return keyBit(leaves[i].Key, depth)
+	})
+	return leaves[:idx], leaves[idx:]
+}
+
+func keyBit(key HashValue, depth int) bool {
+	return key[depth/8]&(0x80>>uint(depth%8)) != 0
+}
+
+func placeholderToEmpty(h HashValue) []byte {
+	if sha3libra.Equal(h, sha3libra.SparseMerklePlaceholderHash) {
+		return nil
+	}
+	return h
+}
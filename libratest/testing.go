@@ -0,0 +1,78 @@
+// +build !js
+
+package libratest
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/types"
+)
+
+// Account is an account with an Ed25519 key pair, for tests.
+type Account struct {
+	Address    types.AccountAddress
+	PrivateKey ed25519.PrivateKey
+}
+
+// NewAccount creates an account with a random key pair and the balance in the ledger.
+func NewAccount(t testing.TB, l *Ledger, balance uint64) *Account {
+	pubkey, prikey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	a := &Account{Address: client.PubkeyMustToAddress(pubkey), PrivateKey: prikey}
+	require.NoError(t, l.CreateAccount(a.Address, client.PubkeyMustToAuthKey(pubkey), balance))
+	return a
+}
+
+// Transfer submits a P2P transaction through the client, which expires in a minute.
+func Transfer(t testing.TB, c *client.Client, from, to *Account, seq, amount uint64) {
+	rawTxn, err := client.NewRawP2PTransaction(from.Address, to.Address, nil, seq, amount, 10000, 0, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	_, err = c.SubmitRawTransaction(context.Background(), rawTxn, from.PrivateKey)
+	require.NoError(t, err)
+}
+
+// CommitBlock commits a block metadata transaction of the round to the ledger.
+func CommitBlock(t testing.TB, l *Ledger, round uint64) {
+	require.NoError(t, l.Commit(&TransactionToCommit{
+		Transaction: &types.Transaction{Transaction: &types.BlockMetaData{Round: round}},
+		MajorStatus: types.EXECUTED,
+	}))
+}
+
+// StartServer starts a server on the ledger, and connects a client to it.
+// Both are closed when the test finishes.
+func StartServer(t testing.TB, l *Ledger) (*Server, *client.Client) {
+	s := NewServer(l)
+	addr, err := s.Start()
+	require.NoError(t, err)
+	c, err := client.New(addr, l.Waypoint())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c.Close()
+		s.Stop()
+	})
+	return s, c
+}
+
+// StartServers starts a server on each ledger, and returns the servers and their addresses.
+// The servers are stopped when the test finishes.
+func StartServers(t testing.TB, ledgers ...*Ledger) ([]*Server, []string) {
+	var servers []*Server
+	var addrs []string
+	for _, l := range ledgers {
+		s := NewServer(l)
+		addr, err := s.Start()
+		require.NoError(t, err)
+		t.Cleanup(s.Stop)
+		servers = append(servers, s)
+		addrs = append(addrs, addr)
+	}
+	return servers, addrs
+}
@@ -0,0 +1,247 @@
+/*
+Package mirror keeps a local verified copy of the ledger, with all transactions and events,
+in an embedded on-disk store.
+
+A Mirror syncs transactions chunk by chunk through a client. Each chunk is verified with its
+accumulator range proof against a signed ledger info, and the left siblings of the proof are
+checked against the accumulator of the mirror, which is then extended leaf by leaf. Thus the
+stored transactions are always a prefix of the ledger, and whenever the mirror catches up with
+a ledger info, its root hash matches the one signed by the validators.
+
+Once synced, analytics can query the Store locally, instead of querying the servers.
+
+Usage:
+
+	s, err := mirror.Open("/path/to/mirror")
+	...
+	defer s.Close()
+	m := mirror.New(c, s)
+	go m.Run(ctx)
+	...
+	txns, err := s.Transactions(start, limit)
+*/
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/crypto/sha3libra"
+	"github.com/the729/go-libra/types"
+)
+
+// Default settings of Mirror.
+const (
+	DefaultChunkSize    = 100
+	DefaultPollInterval = time.Second
+	DefaultMaxBackoff   = time.Minute
+)
+
+// Mirror syncs transactions and events from a Libra client into a Store.
+type Mirror struct {
+	// ChunkSize is the max number of transactions per query. Servers may have a limit on it.
+	ChunkSize uint64
+
+	// PollInterval is the wait time before syncing again in Run, after the tip of the ledger
+	// is reached.
+	PollInterval time.Duration
+
+	// MaxBackoff caps the wait time before retrying in Run, after transient RPC failures.
+	// The wait time starts from PollInterval, and doubles after each failure.
+	MaxBackoff time.Duration
+
+	// OnError, if not nil, is called in Run with each transient RPC failure, before retrying.
+	OnError func(err error)
+
+	c *client.Client
+	s *Store
+
+	// syncMu serializes syncing
+	syncMu     sync.Mutex
+	liMu       sync.RWMutex
+	ledgerInfo *types.ProvenLedgerInfo
+}
+
+// New creates a Mirror, which syncs through the client into the store. Settings of the
+// mirror can be changed before syncing.
+func New(c *client.Client, s *Store) *Mirror {
+	return &Mirror{
+		ChunkSize:    DefaultChunkSize,
+		PollInterval: DefaultPollInterval,
+		MaxBackoff:   DefaultMaxBackoff,
+		c:            c,
+		s:            s,
+	}
+}
+
+// Store returns the store of the mirror.
+func (m *Mirror) Store() *Store {
+	return m.s
+}
+
+// LedgerInfo returns the latest ledger info, of which the root hash matches the mirror. It
+// returns nil if the mirror has not caught up with any ledger info since created.
+func (m *Mirror) LedgerInfo() *types.ProvenLedgerInfo {
+	m.liMu.RLock()
+	defer m.liMu.RUnlock()
+	return m.ledgerInfo
+}
+
+// SyncOnce fetches, verifies and stores the next chunk of transactions. It returns the
+// number of new transactions, which is 0 if the mirror has reached the tip of the ledger.
+func (m *Mirror) SyncOnce(ctx context.Context) (int, error) {
+	m.syncMu.Lock()
+	defer m.syncMu.Unlock()
+
+	acc := m.s.Accumulator()
+	start := acc.NumLeaves
+	txnList, ptl, err := m.c.QueryTransactionListWithProof(ctx, start, m.ChunkSize, true)
+	if err != nil {
+		return 0, err
+	}
+	pli := ptl.GetLedgerInfo()
+	if pli.GetVersion()+1 < start {
+		return 0, fmt.Errorf("ledger version %d is behind the mirror of %d transactions", pli.GetVersion(), start)
+	}
+	expected := pli.GetVersion() - start + 1
+	if expected > m.ChunkSize {
+		expected = m.ChunkSize
+	}
+	txns := txnList.Transactions
+	if uint64(len(txns)) != expected {
+		return 0, fmt.Errorf("incomplete transaction list: expecting %d transactions from version %d, got %d", expected, start, len(txns))
+	}
+	if len(txns) == 0 {
+		return 0, m.checkRootHash(pli)
+	}
+	if txns[0].Version != start {
+		return 0, fmt.Errorf("transaction list starts from version %d, expecting %d", txns[0].Version, start)
+	}
+	for _, ptxn := range ptl.GetTransactions() {
+		if !ptxn.GetWithEvents() {
+			return 0, fmt.Errorf("events of transaction %d not found", ptxn.GetVersion())
+		}
+	}
+
+	// The left siblings of the first transaction are the frozen subtrees of the accumulator
+	// of the preceding transactions, from bottom to top. The range proof has been verified
+	// against the ledger info, so the mirror is proven to be a prefix of the ledger.
+	leftSiblings := txnList.Proof.LeftSiblings
+	if len(leftSiblings) != len(acc.FrozenSubtreeRoots) {
+		return 0, errors.New("range proof inconsistent with the mirror")
+	}
+	for i, h := range leftSiblings {
+		if !sha3libra.Equal(h, acc.FrozenSubtreeRoots[len(leftSiblings)-1-i]) {
+			return 0, errors.New("range proof inconsistent with the mirror")
+		}
+	}
+	for _, txn := range txns {
+		if err := acc.AppendOne(txn.Info.Hash()); err != nil {
+			return 0, fmt.Errorf("extend accumulator error: %v", err)
+		}
+	}
+	if acc.NumLeaves == pli.GetVersion()+1 {
+		rootHash, err := acc.RootHash()
+		if err != nil {
+			return 0, err
+		}
+		if !sha3libra.Equal(rootHash, pli.GetTransactionAccumulatorHash()) {
+			return 0, errors.New("root hash of the mirror does not match the ledger info")
+		}
+	}
+
+	if err := m.s.Append(txns, acc); err != nil {
+		return 0, fmt.Errorf("store transactions error: %v", err)
+	}
+	if acc.NumLeaves == pli.GetVersion()+1 {
+		m.setLedgerInfo(pli)
+	}
+	return len(txns), nil
+}
+
+// checkRootHash checks the root hash of the mirror, which has caught up with the ledger info.
+func (m *Mirror) checkRootHash(pli *types.ProvenLedgerInfo) error {
+	rootHash, err := m.s.RootHash()
+	if err != nil {
+		return err
+	}
+	if !sha3libra.Equal(rootHash, pli.GetTransactionAccumulatorHash()) {
+		return errors.New("root hash of the mirror does not match the ledger info")
+	}
+	m.setLedgerInfo(pli)
+	return nil
+}
+
+func (m *Mirror) setLedgerInfo(pli *types.ProvenLedgerInfo) {
+	m.liMu.Lock()
+	defer m.liMu.Unlock()
+	if m.ledgerInfo == nil || m.ledgerInfo.GetVersion() <= pli.GetVersion() {
+		m.ledgerInfo = pli
+	}
+}
+
+// Sync syncs the mirror until it reaches the tip of the ledger.
+func (m *Mirror) Sync(ctx context.Context) error {
+	for {
+		n, err := m.SyncOnce(ctx)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
+
+// Run keeps syncing the mirror, until the context is done, or an error other than a transient
+// RPC failure occurs, e.g. a verification failure. Transient RPC failures are reported to
+// OnError, and retried with exponential backoff. If the context is done, ctx.Err() is returned.
+func (m *Mirror) Run(ctx context.Context) error {
+	backoff := m.PollInterval
+	for {
+		wait := m.PollInterval
+		if err := m.Sync(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !isTransient(err) {
+				return err
+			}
+			if m.OnError != nil {
+				m.OnError(err)
+			}
+			if backoff <= 0 {
+				backoff = DefaultPollInterval
+			}
+			wait = backoff
+			if backoff *= 2; m.MaxBackoff > 0 && backoff > m.MaxBackoff {
+				backoff = m.MaxBackoff
+			}
+		} else {
+			backoff = m.PollInterval
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// isTransient tells whether the error is an RPC failure which may succeed if retried.
+func isTransient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Canceled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package mirror_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/generated/pbtypes"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/mirror"
+	"github.com/the729/go-libra/types"
+)
+
+func openStore(t *testing.T, dir string) *mirror.Store {
+	s, err := mirror.Open(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+	l := libratest.NewLedger(4)
+	alice := libratest.NewAccount(t, l, 1000)
+	bob := libratest.NewAccount(t, l, 0)
+	server, c := libratest.StartServer(t, l)
+	for seq := uint64(0); seq < 3; seq++ {
+		libratest.Transfer(t, c, alice, bob, seq, 10)
+	}
+	dir := t.TempDir()
+
+	assertSynced := func(t *testing.T, m *mirror.Mirror) {
+		s := m.Store()
+		require.Equal(t, l.Version()+1, s.NumTransactions())
+		pli := m.LedgerInfo()
+		require.NotNil(t, pli)
+		assert.Equal(t, l.Version(), pli.GetVersion())
+		rootHash, err := s.RootHash()
+		require.NoError(t, err)
+		assert.Equal(t, pli.GetTransactionAccumulatorHash(), []byte(rootHash))
+
+		ptl, err := c.QueryTransactionRange(ctx, 0, s.NumTransactions(), true)
+		require.NoError(t, err)
+		txns, err := s.Transactions(0, 1000)
+		require.NoError(t, err)
+		require.Len(t, txns, len(ptl.GetTransactions()))
+		for i, ptxn := range ptl.GetTransactions() {
+			assert.Equal(t, ptxn.GetVersion(), txns[i].Version)
+			assert.Equal(t, ptxn.GetHash(), txns[i].Info.Hash())
+			events := ptxn.GetEvents()
+			require.Len(t, txns[i].Events, len(events))
+			for j, ev := range events {
+				assert.Equal(t, ev, txns[i].Events[j])
+			}
+		}
+	}
+
+	t.Run("sync", func(t *testing.T) {
+		m := mirror.New(c, openStore(t, dir))
+		m.ChunkSize = 3
+		require.NoError(t, m.Sync(ctx))
+		assertSynced(t, m)
+
+		_, err := m.Store().Transaction(l.Version() + 1)
+		assert.Equal(t, mirror.ErrNotFound, err)
+
+		ar, err := c.QueryAccountState(ctx, bob.Address)
+		require.NoError(t, err)
+		res, err := ar.GetAccountBlob().GetLibraAccountResource()
+		require.NoError(t, err)
+		events, err := m.Store().Events(res.ReceivedEvents.Key, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		for i, ev := range events {
+			ev0 := ev.Event.Value.(*types.ContractEventV0)
+			assert.Equal(t, uint64(i+1), ev0.SequenceNumber)
+			txn, err := m.Store().Transaction(ev.TransactionVersion)
+			require.NoError(t, err)
+			assert.Equal(t, ev.Event, txn.Events[ev.EventIndex])
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		libratest.Transfer(t, c, alice, bob, 3, 10)
+		m := mirror.New(c, openStore(t, dir))
+		require.NoError(t, m.Sync(ctx))
+		assertSynced(t, m)
+
+		events, err := m.Store().Events(types.EventKey(nil), 0, 10)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("run", func(t *testing.T) {
+		m := mirror.New(c, openStore(t, dir))
+		m.PollInterval = 10 * time.Millisecond
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- m.Run(ctx) }()
+		libratest.CommitBlock(t, l, 100)
+		assert.Eventually(t, func() bool {
+			return m.Store().NumTransactions() == l.Version()+1
+		}, time.Second, 10*time.Millisecond)
+		cancel()
+		assert.Equal(t, context.Canceled, <-done)
+	})
+
+	t.Run("run retries transient failures", func(t *testing.T) {
+		_, addrs := libratest.StartServers(t, l)
+		var failures int32 = 3
+		flaky := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				return status.Error(codes.Unavailable, "injected failure")
+			}
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		c, err := client.NewWithOptions(addrs[0], l.Waypoint(), client.WithUnaryInterceptors(flaky))
+		require.NoError(t, err)
+		defer c.Close()
+
+		m := mirror.New(c, openStore(t, dir))
+		m.PollInterval = time.Millisecond
+		var errs []error
+		m.OnError = func(err error) { errs = append(errs, err) }
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- m.Run(ctx) }()
+		libratest.CommitBlock(t, l, 102)
+		assert.Eventually(t, func() bool {
+			return m.Store().NumTransactions() == l.Version()+1
+		}, time.Second, 10*time.Millisecond)
+		cancel()
+		assert.Equal(t, context.Canceled, <-done)
+		require.Len(t, errs, 3)
+		assert.Equal(t, codes.Unavailable, status.Code(errs[0]))
+	})
+
+	t.Run("recover from partial append", func(t *testing.T) {
+		s := openStore(t, dir)
+		n := s.NumTransactions()
+		require.NoError(t, s.Close())
+		for _, name := range []string{"transactions.dat", "transactions.idx"} {
+			f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_APPEND, 0644)
+			require.NoError(t, err)
+			_, err = f.Write([]byte("garbage!garbage!"))
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		}
+
+		m := mirror.New(c, openStore(t, dir))
+		assert.Equal(t, n, m.Store().NumTransactions())
+		libratest.CommitBlock(t, l, 101)
+		require.NoError(t, m.Sync(ctx))
+		assertSynced(t, m)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		tamper func(*pbtypes.TransactionListWithProof)
+	}{
+		{"withheld events", func(list *pbtypes.TransactionListWithProof) {
+			list.EventsForVersions = nil
+		}},
+		{"truncated", func(list *pbtypes.TransactionListWithProof) {
+			list.Transactions = list.Transactions[:len(list.Transactions)-1]
+			list.Proof.TransactionInfos = list.Proof.TransactionInfos[:len(list.Proof.TransactionInfos)-1]
+			list.EventsForVersions.EventsForVersion = list.EventsForVersions.EventsForVersion[:len(list.EventsForVersions.EventsForVersion)-1]
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			seq, err := c.QueryAccountSequenceNumber(ctx, alice.Address)
+			require.NoError(t, err)
+			libratest.Transfer(t, c, alice, bob, seq, 10)
+			server.TamperResponse = func(resp *pbtypes.UpdateToLatestLedgerResponse) {
+				if r := resp.ResponseItems[0].GetGetTransactionsResponse(); r != nil && len(r.TxnListWithProof.Transactions) > 0 {
+					tc.tamper(r.TxnListWithProof)
+				}
+			}
+			defer func() { server.TamperResponse = nil }()
+			m := mirror.New(c, openStore(t, dir))
+			assert.Error(t, m.Sync(ctx))
+		})
+	}
+
+	t.Run("fork", func(t *testing.T) {
+		s := openStore(t, dir)
+		require.NoError(t, mirror.New(c, s).Sync(ctx))
+
+		// a ledger of another genesis diverges from the first transaction
+		l2 := libratest.NewLedger(4)
+		libratest.CommitBlock(t, l2, 1)
+		for i := uint64(0); i <= l.Version(); i++ {
+			libratest.CommitBlock(t, l2, 200+i)
+		}
+		_, c2 := libratest.StartServer(t, l2)
+		m := mirror.New(c2, s)
+		assert.Error(t, m.Sync(ctx))
+		assert.Equal(t, l.Version()+1, s.NumTransactions())
+	})
+}
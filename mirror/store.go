@@ -0,0 +1,378 @@
+package mirror
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/the729/lcs"
+
+	"github.com/the729/go-libra/crypto/sha3libra"
+	"github.com/the729/go-libra/internal/fileutil"
+	"github.com/the729/go-libra/types"
+	"github.com/the729/go-libra/types/proof/accumulator"
+)
+
+// ErrNotFound is returned when the requested transaction is not in the store yet.
+var ErrNotFound = errors.New("not found in mirror")
+
+const (
+	dataFile        = "transactions.dat"
+	indexFile       = "transactions.idx"
+	accumulatorFile = "accumulator.json"
+)
+
+// Store is an embedded on-disk store of transactions, with their infos and events, and the
+// accumulator built from the transaction infos. It is safe for concurrent use.
+//
+// A store is a directory of 3 files:
+//   - transactions.dat: records of transactions, each prefixed with its length
+//   - transactions.idx: offsets of records, 8 bytes per transaction
+//   - accumulator.json: the accumulator, which is replaced atomically after each append
+//
+// Only transactions covered by the accumulator are valid. Records beyond it, e.g. left by
+// a crash during an append, are discarded when the store is opened.
+//
+// Events are indexed by event key in memory, when the store is opened.
+type Store struct {
+	mu      sync.RWMutex
+	dir     string
+	data    *os.File
+	index   *os.File
+	offsets []int64
+	size    int64
+	acc     *accumulator.Accumulator
+	events  map[string][]eventPos
+}
+
+type eventPos struct {
+	seq     uint64
+	version uint64
+	index   uint64
+}
+
+// record is the stored form of a transaction.
+type record struct {
+	RawTxn []byte
+	Info   *types.TransactionInfo
+	Events types.EventList
+}
+
+type accumulatorState struct {
+	NumLeaves uint64   `json:"num_leaves"`
+	Subtrees  []string `json:"subtrees"`
+}
+
+// Event is an event in the store.
+type Event struct {
+	TransactionVersion uint64
+	EventIndex         uint64
+	Event              *types.ContractEvent
+}
+
+// Open opens the store in the directory, which is created if it does not exist.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &Store{
+		dir: dir,
+		acc: &accumulator.Accumulator{
+			Hasher: sha3libra.NewTransactionAccumulator(),
+		},
+		events: make(map[string][]eventPos),
+	}
+	if err := s.loadAccumulator(); err != nil {
+		return nil, err
+	}
+	var err error
+	if s.data, err = os.OpenFile(filepath.Join(dir, dataFile), os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		return nil, err
+	}
+	if s.index, err = os.OpenFile(filepath.Join(dir, indexFile), os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		s.data.Close()
+		return nil, err
+	}
+	if err := s.load(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) loadAccumulator() error {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, accumulatorFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	state := &accumulatorState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("accumulator file corrupted: %v", err)
+	}
+	for _, st := range state.Subtrees {
+		h, err := hex.DecodeString(st)
+		if err != nil {
+			return fmt.Errorf("accumulator file corrupted: %v", err)
+		}
+		s.acc.FrozenSubtreeRoots = append(s.acc.FrozenSubtreeRoots, h)
+	}
+	s.acc.NumLeaves = state.NumLeaves
+	if _, err := s.acc.RootHash(); err != nil {
+		return fmt.Errorf("accumulator file corrupted: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) saveAccumulator(acc *accumulator.Accumulator) error {
+	state := &accumulatorState{NumLeaves: acc.NumLeaves}
+	for _, h := range acc.FrozenSubtreeRoots {
+		state.Subtrees = append(state.Subtrees, hex.EncodeToString(h))
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(filepath.Join(s.dir, accumulatorFile), data)
+}
+
+// load reads the offsets, discards records beyond the accumulator, and indexes the events.
+func (s *Store) load() error {
+	n := s.acc.NumLeaves
+	idx, err := ioutil.ReadAll(s.index)
+	if err != nil {
+		return err
+	}
+	if uint64(len(idx)/8) < n {
+		return fmt.Errorf("index file corrupted: %d transactions, expecting %d", len(idx)/8, n)
+	}
+	s.offsets = make([]int64, n)
+	for i := range s.offsets {
+		s.offsets[i] = int64(binary.BigEndian.Uint64(idx[i*8:]))
+	}
+	s.size = 0
+	if n > 0 {
+		var lenBuf [4]byte
+		if _, err := s.data.ReadAt(lenBuf[:], s.offsets[n-1]); err != nil {
+			return fmt.Errorf("data file corrupted: %v", err)
+		}
+		s.size = s.offsets[n-1] + 4 + int64(binary.BigEndian.Uint32(lenBuf[:]))
+	}
+	if err := s.index.Truncate(int64(n) * 8); err != nil {
+		return err
+	}
+	if err := s.data.Truncate(s.size); err != nil {
+		return err
+	}
+
+	for v := uint64(0); v < n; v++ {
+		rec, err := s.readRecord(v)
+		if err != nil {
+			return err
+		}
+		s.indexEvents(v, rec.Events)
+	}
+	return nil
+}
+
+func (s *Store) indexEvents(version uint64, events types.EventList) {
+	for i, ev := range events {
+		ev0, ok := ev.Value.(*types.ContractEventV0)
+		if !ok {
+			continue
+		}
+		key := string(ev0.Key)
+		s.events[key] = append(s.events[key], eventPos{ev0.SequenceNumber, version, uint64(i)})
+	}
+}
+
+func (s *Store) readRecord(version uint64) (*record, error) {
+	var lenBuf [4]byte
+	if _, err := s.data.ReadAt(lenBuf[:], s.offsets[version]); err != nil {
+		return nil, fmt.Errorf("read transaction %d error: %v", version, err)
+	}
+	buf := make([]byte, binary.BigEndian.Uint32(lenBuf[:]))
+	if _, err := s.data.ReadAt(buf, s.offsets[version]+4); err != nil {
+		return nil, fmt.Errorf("read transaction %d error: %v", version, err)
+	}
+	rec := &record{}
+	if err := lcs.Unmarshal(buf, rec); err != nil {
+		return nil, fmt.Errorf("transaction %d corrupted: %v", version, err)
+	}
+	return rec, nil
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.data.Close()
+	if err1 := s.index.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// NumTransactions returns the number of transactions in the store, i.e. the transactions
+// of version 0 to NumTransactions()-1 are stored.
+func (s *Store) NumTransactions() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.acc.NumLeaves
+}
+
+// Accumulator returns a copy of the accumulator of the store.
+func (s *Store) Accumulator() *accumulator.Accumulator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneAccumulator(s.acc)
+}
+
+// RootHash returns the root hash of the accumulator of the store.
+func (s *Store) RootHash() (sha3libra.HashValue, error) {
+	return s.Accumulator().RootHash()
+}
+
+// Append appends transactions, following the stored ones, and replaces the accumulator with
+// acc, which should already include the transaction infos. The transactions are written
+// before the accumulator, so that they are not lost in a crash.
+func (s *Store) Append(txns []*types.SubmittedTransaction, acc *accumulator.Accumulator) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if acc.NumLeaves != s.acc.NumLeaves+uint64(len(txns)) {
+		return fmt.Errorf("accumulator of %d leaves, expecting %d", acc.NumLeaves, s.acc.NumLeaves+uint64(len(txns)))
+	}
+
+	var data, idx []byte
+	offsets := make([]int64, 0, len(txns))
+	offset := s.size
+	for i, txn := range txns {
+		if txn.Version != s.acc.NumLeaves+uint64(i) {
+			return fmt.Errorf("transaction of version %d, expecting %d", txn.Version, s.acc.NumLeaves+uint64(i))
+		}
+		b, err := lcs.Marshal(&record{RawTxn: txn.RawTxn, Info: txn.Info, Events: txn.Events})
+		if err != nil {
+			return err
+		}
+		var lenBuf [4]byte
+		binary.BigEndian.PutUint32(lenBuf[:], uint32(len(b)))
+		data = append(append(data, lenBuf[:]...), b...)
+		var offBuf [8]byte
+		binary.BigEndian.PutUint64(offBuf[:], uint64(offset))
+		idx = append(idx, offBuf[:]...)
+		offsets = append(offsets, offset)
+		offset += int64(4 + len(b))
+	}
+
+	if _, err := s.data.WriteAt(data, s.size); err != nil {
+		return err
+	}
+	if _, err := s.index.WriteAt(idx, int64(len(s.offsets))*8); err != nil {
+		return err
+	}
+	if err := s.data.Sync(); err != nil {
+		return err
+	}
+	if err := s.index.Sync(); err != nil {
+		return err
+	}
+	acc = cloneAccumulator(acc)
+	if err := s.saveAccumulator(acc); err != nil {
+		return err
+	}
+
+	for _, txn := range txns {
+		s.indexEvents(txn.Version, txn.Events)
+	}
+	s.offsets = append(s.offsets, offsets...)
+	s.size = offset
+	s.acc = acc
+	return nil
+}
+
+// Transaction returns the transaction of the version. If it is not in the store yet,
+// ErrNotFound is returned.
+func (s *Store) Transaction(version uint64) (*types.SubmittedTransaction, error) {
+	txns, err := s.Transactions(version, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(txns) == 0 {
+		return nil, ErrNotFound
+	}
+	return txns[0], nil
+}
+
+// Transactions returns at most limit transactions from version start.
+func (s *Store) Transactions(start, limit uint64) ([]*types.SubmittedTransaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := uint64(len(s.offsets))
+	if start >= n {
+		return nil, nil
+	}
+	if n-start < limit {
+		limit = n - start
+	}
+	txns := make([]*types.SubmittedTransaction, 0, limit)
+	for v := start; v < start+limit; v++ {
+		rec, err := s.readRecord(v)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, &types.SubmittedTransaction{
+			RawTxn:  rec.RawTxn,
+			Info:    rec.Info,
+			Events:  rec.Events,
+			Version: v,
+		})
+	}
+	return txns, nil
+}
+
+// Events returns at most limit events of the event key, from sequence number start, in
+// ascending order.
+func (s *Store) Events(key types.EventKey, start, limit uint64) ([]*Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	positions := s.events[string(key)]
+	i := sort.Search(len(positions), func(i int) bool { return positions[i].seq >= start })
+	var out []*Event
+	for ; i < len(positions) && uint64(len(out)) < limit; i++ {
+		pos := positions[i]
+		rec, err := s.readRecord(pos.version)
+		if err != nil {
+			return nil, err
+		}
+		if pos.index >= uint64(len(rec.Events)) {
+			return nil, fmt.Errorf("event %d of transaction %d not found", pos.index, pos.version)
+		}
+		out = append(out, &Event{
+			TransactionVersion: pos.version,
+			EventIndex:         pos.index,
+			Event:              rec.Events[pos.index],
+		})
+	}
+	return out, nil
+}
+
+func cloneAccumulator(acc *accumulator.Accumulator) *accumulator.Accumulator {
+	subtrees := make([]sha3libra.HashValue, 0, len(acc.FrozenSubtreeRoots))
+	for _, h := range acc.FrozenSubtreeRoots {
+		subtrees = append(subtrees, append([]byte(nil), h...))
+	}
+	return &accumulator.Accumulator{
+		Hasher:             sha3libra.NewTransactionAccumulator(),
+		FrozenSubtreeRoots: subtrees,
+		NumLeaves:          acc.NumLeaves,
+	}
+}
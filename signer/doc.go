@@ -0,0 +1,17 @@
+/*
+Package signer provides implementations of types.Signer, which sign transactions
+without handing private keys to the client.
+
+  - Memory: signs with an ed25519 private key kept in memory.
+  - Server and Remote: a signing service over HTTP and its client, so that keys
+    can be kept in a separate process, e.g. on localhost.
+
+Any types.Signer can be used to sign and submit transactions:
+
+	s, err := signer.NewRemote("http://127.0.0.1:8765", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	seq, err := c.SubmitRawTransaction(ctx, rawTxn, s)
+*/
+package signer
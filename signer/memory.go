@@ -0,0 +1,56 @@
+package signer
+
+import (
+	"crypto"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/the729/go-libra/crypto/sha3libra"
+)
+
+// Memory is a signer holding an ed25519 private key in memory. It only signs
+// transaction hashes, and never exposes the private key.
+type Memory struct {
+	key ed25519.PrivateKey
+}
+
+// NewMemory creates an in-memory signer from a private key. The key is copied.
+func NewMemory(key ed25519.PrivateKey) (*Memory, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("wrong private key length %d", len(key))
+	}
+	return &Memory{key: append(ed25519.PrivateKey(nil), key...)}, nil
+}
+
+// NewMemoryFromSeed creates an in-memory signer from a 32-byte private key seed.
+func NewMemoryFromSeed(seed []byte) (*Memory, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("wrong seed length %d", len(seed))
+	}
+	return &Memory{key: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// GenerateMemory creates an in-memory signer with a new random private key.
+// If rand is nil, crypto/rand.Reader is used.
+func GenerateMemory(rand io.Reader) (*Memory, error) {
+	_, key, err := ed25519.GenerateKey(rand)
+	if err != nil {
+		return nil, err
+	}
+	return &Memory{key: key}, nil
+}
+
+// Public returns the ed25519.PublicKey of the signer.
+func (m *Memory) Public() crypto.PublicKey {
+	return m.key.Public()
+}
+
+// Sign signs a transaction hash. rand and opts are ignored.
+func (m *Memory) Sign(rand io.Reader, txnHash []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if len(txnHash) != sha3libra.HashSize {
+		return nil, fmt.Errorf("wrong hash length %d", len(txnHash))
+	}
+	return ed25519.Sign(m.key, txnHash), nil
+}
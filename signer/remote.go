@@ -0,0 +1,184 @@
+package signer
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/the729/go-libra/crypto/sha3libra"
+	"github.com/the729/go-libra/types"
+)
+
+// The remote signer protocol is JSON over HTTP, with bytes in hex:
+//   GET  /public_key  -> {"public_key": "..."}
+//   POST /sign        {"txn_hash": "..."} -> {"signature": "..."}
+// Errors are returned as non-200 status codes with plain text messages.
+
+type publicKeyResponse struct {
+	PublicKey string `json:"public_key"`
+}
+
+type signRequest struct {
+	TxnHash string `json:"txn_hash"`
+}
+
+type signResponse struct {
+	Signature string `json:"signature"`
+}
+
+// DefaultRemoteTimeout is the default timeout of requests to the remote signer.
+const DefaultRemoteTimeout = 10 * time.Second
+
+// Server serves a signer over HTTP, to be used by Remote. It implements http.Handler.
+//
+// The server does not authenticate clients. It should listen on localhost, or be put
+// behind an authenticating proxy.
+type Server struct {
+	signer types.Signer
+	pubkey ed25519.PublicKey
+}
+
+// NewServer creates a server of a signer.
+func NewServer(signer types.Signer) (*Server, error) {
+	pubkey, ok := signer.Public().(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("signer public key is not ed25519 public key")
+	}
+	return &Server{signer: signer, pubkey: pubkey}, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/public_key":
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, &publicKeyResponse{PublicKey: hex.EncodeToString(s.pubkey)})
+	case "/sign":
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		req := &signRequest{}
+		if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(req); err != nil {
+			http.Error(w, fmt.Sprintf("bad request: %v", err), http.StatusBadRequest)
+			return
+		}
+		txnHash, err := hex.DecodeString(req.TxnHash)
+		if err != nil || len(txnHash) != sha3libra.HashSize {
+			http.Error(w, "bad request: txn_hash is not a 32-byte hex string", http.StatusBadRequest)
+			return
+		}
+		sig, err := s.signer.Sign(nil, txnHash, crypto.Hash(0))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("sign error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, &signResponse{Signature: hex.EncodeToString(sig)})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Remote is a signer which asks a remote Server to sign. Every signature is verified
+// against the public key of the server, which is fetched once when Remote is created.
+type Remote struct {
+	url    string
+	client *http.Client
+	pubkey ed25519.PublicKey
+}
+
+// NewRemote connects to a remote signer server at url, e.g. "http://127.0.0.1:8765",
+// and fetches its public key. If httpClient is nil, a client with DefaultRemoteTimeout
+// is used.
+func NewRemote(url string, httpClient *http.Client) (*Remote, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultRemoteTimeout}
+	}
+	r := &Remote{
+		url:    strings.TrimRight(url, "/"),
+		client: httpClient,
+	}
+	resp := &publicKeyResponse{}
+	if err := r.do(http.MethodGet, "/public_key", nil, resp); err != nil {
+		return nil, err
+	}
+	pubkey, err := hex.DecodeString(resp.PublicKey)
+	if err != nil || len(pubkey) != ed25519.PublicKeySize {
+		return nil, errors.New("remote signer returns invalid public key")
+	}
+	r.pubkey = pubkey
+	return r, nil
+}
+
+// Public returns the ed25519.PublicKey of the remote signer.
+func (r *Remote) Public() crypto.PublicKey {
+	return r.pubkey
+}
+
+// Sign asks the remote signer to sign a transaction hash. rand and opts are ignored.
+func (r *Remote) Sign(rand io.Reader, txnHash []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if len(txnHash) != sha3libra.HashSize {
+		return nil, fmt.Errorf("wrong hash length %d", len(txnHash))
+	}
+	resp := &signResponse{}
+	if err := r.do(http.MethodPost, "/sign", &signRequest{TxnHash: hex.EncodeToString(txnHash)}, resp); err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returns invalid signature: %v", err)
+	}
+	if !ed25519.Verify(r.pubkey, txnHash, sig) {
+		return nil, errors.New("remote signer returns wrong signature")
+	}
+	return sig, nil
+}
+
+func (r *Remote) do(method, path string, req, resp interface{}) error {
+	var body io.Reader
+	if req != nil {
+		buf, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+	httpReq, err := http.NewRequest(method, r.url+path, body)
+	if err != nil {
+		return err
+	}
+	if req != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpResp, err := r.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("remote signer request error: %v", err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(httpResp.Body, 1024))
+		return fmt.Errorf("remote signer error: %s: %s", httpResp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return fmt.Errorf("remote signer response error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package signer_test
+
+import (
+	"crypto"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/the729/go-libra/signer"
+	"github.com/the729/go-libra/types"
+)
+
+func newRawTxn() *types.RawTransaction {
+	return &types.RawTransaction{
+		Sender:         types.AccountAddress{1},
+		SequenceNumber: 5,
+		Payload:        &types.TxnPayloadScript{Code: []byte{1, 2, 3}},
+		MaxGasAmount:   1000,
+		GasSpecifier:   types.LBRTypeTag(),
+		ExpirationTime: uint64(time.Now().Unix()),
+	}
+}
+
+func TestMemory(t *testing.T) {
+	mem, err := signer.GenerateMemory(nil)
+	require.NoError(t, err)
+
+	stxn, err := newRawTxn().Sign(mem)
+	require.NoError(t, err)
+	assert.NoError(t, stxn.VerifySignature())
+	assert.Equal(t, mem.Public(), ed25519.PublicKey(stxn.Authenticator.(*types.ED25519Authenticator).PublicKey))
+
+	_, err = mem.Sign(nil, []byte{1, 2, 3}, crypto.Hash(0))
+	assert.Error(t, err)
+}
+
+func TestRemote(t *testing.T) {
+	mem, err := signer.GenerateMemory(nil)
+	require.NoError(t, err)
+	srv, err := signer.NewServer(mem)
+	require.NoError(t, err)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	t.Run("sign", func(t *testing.T) {
+		remote, err := signer.NewRemote(ts.URL, nil)
+		require.NoError(t, err)
+		assert.Equal(t, mem.Public(), remote.Public())
+
+		stxn, err := newRawTxn().Sign(remote)
+		require.NoError(t, err)
+		assert.NoError(t, stxn.VerifySignature())
+	})
+
+	t.Run("wrong signature", func(t *testing.T) {
+		other, err := signer.GenerateMemory(nil)
+		require.NoError(t, err)
+		otherSrv, err := signer.NewServer(other)
+		require.NoError(t, err)
+
+		// the server switches key after the public key is fetched
+		var h http.Handler = srv
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r)
+		}))
+		defer ts.Close()
+		remote, err := signer.NewRemote(ts.URL, nil)
+		require.NoError(t, err)
+		h = otherSrv
+		_, err = newRawTxn().Sign(remote)
+		assert.Error(t, err)
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		remote, err := signer.NewRemote(ts.URL, nil)
+		require.NoError(t, err)
+		_, err = remote.Sign(nil, []byte{1, 2, 3}, crypto.Hash(0))
+		assert.Error(t, err)
+
+		resp, err := http.Get(ts.URL + "/sign")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+}
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/the729/go-libra/common/bech32"
+)
+
+// SubAddressLength is the length of a subaddress, which is 8 bytes.
+const SubAddressLength = 8
+
+// SubAddress is an identifier of a user within an account, e.g. a customer of a merchant.
+// A zero subaddress means no subaddress.
+type SubAddress [SubAddressLength]byte
+
+// IsZero returns whether the subaddress is absent.
+func (s SubAddress) IsZero() bool {
+	return s == SubAddress{}
+}
+
+// Network prefixes of account identifiers.
+const (
+	MainnetPrefix = "lbr"
+	TestnetPrefix = "tlb"
+)
+
+// accountIdentifierVersion is the version of account identifier format.
+const accountIdentifierVersion = 1
+
+// AccountIdentifier is a human-safe representation of an account address, with an optional
+// subaddress, and a network prefix. It is encoded with bech32: the network prefix as the
+// human readable part, and the version followed by address and subaddress as the data.
+// The checksum detects typos.
+//
+// E.g. "lbr1p7ujcndcl7nudzwt8fglhx6wxn08kgs5tm6mz4usw5p72t" is an identifier on mainnet.
+type AccountIdentifier struct {
+	// Prefix is the network prefix, MainnetPrefix or TestnetPrefix.
+	Prefix string
+
+	// Address is the account address.
+	Address AccountAddress
+
+	// SubAddress is the subaddress. A zero subaddress means no subaddress.
+	SubAddress SubAddress
+}
+
+// String encodes the account identifier. It returns an empty string if the prefix is invalid.
+func (id *AccountIdentifier) String() string {
+	s, err := id.Encode()
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// Encode encodes the account identifier into a bech32 string.
+func (id *AccountIdentifier) Encode() (string, error) {
+	if id.Prefix != MainnetPrefix && id.Prefix != TestnetPrefix {
+		return "", fmt.Errorf("unknown network prefix %q", id.Prefix)
+	}
+	payload := make([]byte, 0, AccountAddressLength+SubAddressLength)
+	payload = append(payload, id.Address[:]...)
+	payload = append(payload, id.SubAddress[:]...)
+	data, err := bech32.ConvertBits(payload, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(id.Prefix, append([]byte{accountIdentifierVersion}, data...))
+}
+
+// ParseAccountIdentifier parses a bech32 account identifier, and verifies its checksum.
+func ParseAccountIdentifier(s string) (*AccountIdentifier, error) {
+	prefix, data, err := bech32.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != MainnetPrefix && prefix != TestnetPrefix {
+		return nil, fmt.Errorf("unknown network prefix %q", prefix)
+	}
+	if len(data) == 0 || data[0] != accountIdentifierVersion {
+		return nil, fmt.Errorf("unsupported account identifier version")
+	}
+	payload, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != AccountAddressLength+SubAddressLength {
+		return nil, fmt.Errorf("wrong account identifier payload length %d", len(payload))
+	}
+	id := &AccountIdentifier{Prefix: prefix}
+	copy(id.Address[:], payload)
+	copy(id.SubAddress[:], payload[AccountAddressLength:])
+	return id, nil
+}
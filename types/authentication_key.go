@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// AuthenticationKeyLength is the length of an authentication key, which is 32 bytes.
+	AuthenticationKeyLength = 32
+
+	// AuthenticationKeyPrefixLength is the length of an authentication key prefix, which is
+	// the part of the authentication key not in the derived address.
+	AuthenticationKeyPrefixLength = AuthenticationKeyLength - AccountAddressLength
+)
+
+// Scheme is the signature scheme of an authentication key.
+type Scheme byte
+
+// Signature schemes.
+const (
+	Ed25519Scheme      Scheme = 0
+	MultiEd25519Scheme Scheme = 1
+)
+
+// AuthenticationKey is the authentication key of an account, which is SHA3-256 of the
+// public key followed by the scheme byte. The last 16 bytes of the authentication key
+// is the derived address of a new account.
+type AuthenticationKey [AuthenticationKeyLength]byte
+
+// NewAuthenticationKey computes the authentication key of a serialized public key of
+// a signature scheme.
+func NewAuthenticationKey(publicKey []byte, scheme Scheme) (out AuthenticationKey) {
+	hasher := sha3.New256()
+	hasher.Write(publicKey)
+	hasher.Write([]byte{byte(scheme)})
+	hasher.Sum(out[:0])
+	return
+}
+
+// Ed25519AuthenticationKey computes the authentication key of an ed25519 public key.
+func Ed25519AuthenticationKey(publicKey ed25519.PublicKey) (AuthenticationKey, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return AuthenticationKey{}, fmt.Errorf("wrong public key length %d", len(publicKey))
+	}
+	return NewAuthenticationKey(publicKey, Ed25519Scheme), nil
+}
+
+// MultiEd25519AuthenticationKey computes the authentication key of a multi-signature
+// public key.
+func MultiEd25519AuthenticationKey(publicKey *MultiEd25519PublicKey) (AuthenticationKey, error) {
+	if err := publicKey.validate(); err != nil {
+		return AuthenticationKey{}, err
+	}
+	return NewAuthenticationKey(publicKey.Bytes(), MultiEd25519Scheme), nil
+}
+
+// Prefix returns the first 16 bytes of the authentication key, which is needed to
+// create an account at the derived address.
+func (k AuthenticationKey) Prefix() []byte {
+	return append([]byte{}, k[:AuthenticationKeyPrefixLength]...)
+}
+
+// DerivedAddress returns the last 16 bytes of the authentication key, which is the
+// address of an account created with this authentication key.
+func (k AuthenticationKey) DerivedAddress() (out AccountAddress) {
+	copy(out[:], k[AuthenticationKeyPrefixLength:])
+	return
+}
+
+// UnmarshalText unmarshals the hex representation of an authentication key.
+func (k *AuthenticationKey) UnmarshalText(txt []byte) error {
+	data, err := hex.DecodeString(string(txt))
+	if err != nil {
+		return err
+	}
+	if len(data) != AuthenticationKeyLength {
+		return fmt.Errorf("wrong authentication key length %d", len(data))
+	}
+	copy(k[:], data)
+	return nil
+}
+
+// MarshalText marshals the authentication key into hex representation.
+func (k AuthenticationKey) MarshalText() (text []byte, err error) {
+	return []byte(hex.EncodeToString(k[:])), nil
+}
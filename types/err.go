@@ -5,4 +5,8 @@ import "errors"
 var (
 	// ErrNilInput is error when nil input is not expected.
 	ErrNilInput = errors.New("input is nil")
+
+	// ErrNoNewValidatorChange is error when all ledger infos in a validator change proof
+	// are of epochs already known by the verifier.
+	ErrNoNewValidatorChange = errors.New("no new validator change")
 )
@@ -0,0 +1,280 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/the729/go-libra/generated/pbtypes"
+)
+
+// EventListWithProof is a list of events under an access path, with the latest state of
+// the account. The account state proves the total number of events, so that the event
+// list can be proven to be complete.
+type EventListWithProof struct {
+	EventsWithProof    []*EventWithProof
+	ProofOfLatestEvent *AccountStateWithProof
+}
+
+// ProvenEventList is a list of events under an access path, which has been proven to be
+// included in the ledger. The list of sent or received events of an account is also proven
+// to be complete in the requested range.
+type ProvenEventList struct {
+	proven     bool
+	complete   bool
+	events     []*ProvenEvent
+	totalCount uint64
+	ledgerInfo *ProvenLedgerInfo
+}
+
+// FromProto parses a protobuf struct into this struct.
+func (el *EventListWithProof) FromProto(pb *pbtypes.GetEventsByEventAccessPathResponse) error {
+	if pb == nil {
+		return ErrNilInput
+	}
+	el.EventsWithProof = make([]*EventWithProof, 0, len(pb.EventsWithProof))
+	for _, pbev := range pb.EventsWithProof {
+		ev := &EventWithProof{}
+		if err := ev.FromProto(pbev); err != nil {
+			return fmt.Errorf("event from protobuf error: %v", err)
+		}
+		el.EventsWithProof = append(el.EventsWithProof, ev)
+	}
+	el.ProofOfLatestEvent = &AccountStateWithProof{}
+	if err := el.ProofOfLatestEvent.FromProto(pb.ProofOfLatestEvent); err != nil {
+		return fmt.Errorf("proof of latest event from protobuf error: %v", err)
+	}
+	return nil
+}
+
+// Verify the proof of the event list, which is the result of a query by access path with
+// the given start, ascending and limit parameters. It outputs the proven events if successful.
+//
+// See VerifyList for how the event list is verified.
+func (el *EventListWithProof) Verify(ap *AccessPath, start uint64, ascending bool, limit uint64, ledgerInfo *ProvenLedgerInfo) ([]*ProvenEvent, error) {
+	pel, err := el.VerifyList(ap, start, ascending, limit, ledgerInfo)
+	if err != nil {
+		return nil, err
+	}
+	return pel.events, nil
+}
+
+// VerifyList verifies the proof of the event list, which is the result of a query by access
+// path with the given start, ascending and limit parameters. It outputs a ProvenEventList if
+// successful.
+//
+// For the sent and received event paths of an account, the total number of events is read
+// from the event handle in the latest account state. Verification fails if any event within
+// the requested range is missing, or any event is out of order.
+//
+// For other paths, the event handle is unknown. Each event is verified, and events must be in
+// order, but the list is not proven complete. See ProvenEventList.IsComplete.
+func (el *EventListWithProof) VerifyList(ap *AccessPath, start uint64, ascending bool, limit uint64, ledgerInfo *ProvenLedgerInfo) (*ProvenEventList, error) {
+	sent := bytes.Equal(ap.Path, AccountSentEventPath())
+	if !sent && !bytes.Equal(ap.Path, AccountReceivedEventPath()) {
+		return el.verifyUnknownHandle(start, ascending, limit, ledgerInfo)
+	}
+	if el.ProofOfLatestEvent.Version != ledgerInfo.GetVersion() {
+		return nil, fmt.Errorf("proof of latest event at version %d, expecting %d", el.ProofOfLatestEvent.Version, ledgerInfo.GetVersion())
+	}
+	pas, err := el.ProofOfLatestEvent.Verify(ap.Address, ledgerInfo)
+	if err != nil {
+		return nil, fmt.Errorf("proof of latest event verification error: %v", err)
+	}
+	handle := &EventHandle{}
+	if !pas.IsNil() {
+		ar, err := pas.GetAccountBlob().GetLibraAccountResource()
+		if err != nil {
+			return nil, fmt.Errorf("get account resource error: %v", err)
+		}
+		if sent {
+			handle = ar.SentEvents
+		} else {
+			handle = ar.ReceivedEvents
+		}
+		if handle == nil {
+			return nil, errors.New("nil event handle")
+		}
+	}
+	totalCount := handle.Count
+
+	// the sequence numbers of expected events are first, first+1, ..., first+n-1 if ascending,
+	// or first, first-1, ..., first-n+1 if descending.
+	first, n := start, uint64(0)
+	if ascending {
+		if start < totalCount {
+			n = totalCount - start
+		}
+	} else if totalCount > 0 {
+		if first >= totalCount {
+			first = totalCount - 1
+		}
+		n = first + 1
+	}
+	if n > limit {
+		n = limit
+	}
+	if uint64(len(el.EventsWithProof)) != n {
+		return nil, fmt.Errorf("incomplete event list: expecting %d events, got %d", n, len(el.EventsWithProof))
+	}
+
+	pevs := make([]*ProvenEvent, 0, n)
+	for i, ev := range el.EventsWithProof {
+		ev0, ok := ev.Event.Value.(*ContractEventV0)
+		if !ok {
+			return nil, errors.New("unknown event version")
+		}
+		seq := first + uint64(i)
+		if !ascending {
+			seq = first - uint64(i)
+		}
+		if ev0.SequenceNumber != seq {
+			return nil, fmt.Errorf("unexpected event sequence number: expecting %d, got %d", seq, ev0.SequenceNumber)
+		}
+		if !bytes.Equal(ev0.Key, handle.Key) {
+			return nil, fmt.Errorf("event key mismatch: expecting %x, got %x", handle.Key, ev0.Key)
+		}
+		pev, err := ev.Verify(ledgerInfo)
+		if err != nil {
+			return nil, fmt.Errorf("event verification error: %v", err)
+		}
+		pevs = append(pevs, pev)
+	}
+
+	return &ProvenEventList{
+		proven:     true,
+		complete:   true,
+		events:     pevs,
+		totalCount: totalCount,
+		ledgerInfo: ledgerInfo,
+	}, nil
+}
+
+// verifyUnknownHandle verifies events under an access path other than the sent or received
+// event paths. The first event is the one at start if ascending, and events are consecutive
+// with the same key, but events beyond the last one may be withheld.
+func (el *EventListWithProof) verifyUnknownHandle(start uint64, ascending bool, limit uint64, ledgerInfo *ProvenLedgerInfo) (*ProvenEventList, error) {
+	if uint64(len(el.EventsWithProof)) > limit {
+		return nil, fmt.Errorf("too many events: expecting at most %d, got %d", limit, len(el.EventsWithProof))
+	}
+	pevs := make([]*ProvenEvent, 0, len(el.EventsWithProof))
+	var first uint64
+	var key []byte
+	for i, ev := range el.EventsWithProof {
+		ev0, ok := ev.Event.Value.(*ContractEventV0)
+		if !ok {
+			return nil, errors.New("unknown event version")
+		}
+		if i == 0 {
+			first, key = ev0.SequenceNumber, ev0.Key
+			if (ascending && first != start) || (!ascending && first > start) {
+				return nil, fmt.Errorf("unexpected first event sequence number %d, start %d", first, start)
+			}
+		}
+		seq := first + uint64(i)
+		if !ascending {
+			seq = first - uint64(i)
+		}
+		if ev0.SequenceNumber != seq {
+			return nil, fmt.Errorf("unexpected event sequence number: expecting %d, got %d", seq, ev0.SequenceNumber)
+		}
+		if !bytes.Equal(ev0.Key, key) {
+			return nil, fmt.Errorf("event key mismatch: expecting %x, got %x", key, ev0.Key)
+		}
+		pev, err := ev.Verify(ledgerInfo)
+		if err != nil {
+			return nil, fmt.Errorf("event verification error: %v", err)
+		}
+		pevs = append(pevs, pev)
+	}
+	return &ProvenEventList{
+		proven:     true,
+		events:     pevs,
+		ledgerInfo: ledgerInfo,
+	}, nil
+}
+
+// GetEvents returns a copy of the underlying proven event list.
+func (pel *ProvenEventList) GetEvents() []*ProvenEvent {
+	if !pel.proven {
+		panic("not valid proven event list")
+	}
+	out := make([]*ProvenEvent, len(pel.events))
+	copy(out, pel.events)
+	return out
+}
+
+// IsComplete returns whether the list is proven to be complete in the requested range, with
+// the total number of events under the access path. It is false if the event handle under the
+// access path is unknown, i.e. for paths other than the sent and received event paths.
+func (pel *ProvenEventList) IsComplete() bool {
+	if !pel.proven {
+		panic("not valid proven event list")
+	}
+	return pel.complete
+}
+
+// GetTotalCount returns the total number of events under the access path, at the version
+// of the ledger info. It is 0 if the list is not complete.
+func (pel *ProvenEventList) GetTotalCount() uint64 {
+	if !pel.proven {
+		panic("not valid proven event list")
+	}
+	return pel.totalCount
+}
+
+// GetLedgerInfo returns the ledger info which proofs this event list.
+func (pel *ProvenEventList) GetLedgerInfo() *ProvenLedgerInfo {
+	if !pel.proven {
+		panic("not valid proven event list")
+	}
+	return pel.ledgerInfo
+}
+
+// SubList returns the part of the proven event list with the given start, ascending and
+// limit parameters, which is proven by the same ledger info.
+//
+// The part has exactly limit events, with sequence numbers less than the total count, so that
+// it stays complete after more events are emitted. It returns nil if the list does not cover
+// all these events, or is not complete.
+func (pel *ProvenEventList) SubList(start uint64, ascending bool, limit uint64) *ProvenEventList {
+	if !pel.proven {
+		panic("not valid proven event list")
+	}
+	if !pel.complete || limit == 0 || limit > uint64(len(pel.events)) {
+		return nil
+	}
+	if ascending {
+		if start >= pel.totalCount || pel.totalCount-start < limit {
+			return nil
+		}
+	} else if start >= pel.totalCount || start < limit-1 {
+		return nil
+	}
+
+	// events in the list have consecutive sequence numbers, in either order
+	seq0 := pel.events[0].event.Value.(*ContractEventV0).SequenceNumber
+	descending := len(pel.events) > 1 && pel.events[1].event.Value.(*ContractEventV0).SequenceNumber < seq0
+	pevs := make([]*ProvenEvent, 0, limit)
+	for i := uint64(0); i < limit; i++ {
+		seq := start + i
+		if !ascending {
+			seq = start - i
+		}
+		idx := seq - seq0
+		if descending {
+			idx = seq0 - seq
+		}
+		if (!descending && seq < seq0) || (descending && seq > seq0) || idx >= uint64(len(pel.events)) {
+			return nil
+		}
+		pevs = append(pevs, pel.events[idx])
+	}
+	return &ProvenEventList{
+		proven:     true,
+		complete:   true,
+		events:     pevs,
+		totalCount: pel.totalCount,
+		ledgerInfo: pel.ledgerInfo,
+	}
+}
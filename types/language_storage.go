@@ -131,6 +131,7 @@ func (t *StructTag) Clone() TypeTag {
 	for _, e := range t.TypeParams {
 		n = append(n, e.Clone())
 	}
+	out.TypeParams = n
 	return out
 }
 
@@ -147,6 +147,15 @@ func (pl *ProvenLedgerInfo) GetTimestampUsec() uint64 {
 	return pl.ledgerInfo.TimestampUsec
 }
 
+// GetNextValidatorSet returns the validator set of the next epoch, if this LedgerInfo is at
+// a boundary of epochs. Otherwise it returns nil.
+func (pl *ProvenLedgerInfo) GetNextValidatorSet() *ValidatorSet {
+	if !pl.proven {
+		panic("not valid proven ledger info")
+	}
+	return pl.ledgerInfo.NextValidatorSet.Clone()
+}
+
 // ToVerifier builds a ValidatorVerifier using the next validator set in this
 // LedgerInfo. Only works when this LedgerInfo is at a boundary of epochs.
 func (pl *ProvenLedgerInfo) ToVerifier() (LedgerInfoVerifier, error) {
@@ -0,0 +1,20 @@
+package types
+
+// MempoolStatusCode is the status code of inserting a transaction into mempool.
+type MempoolStatusCode uint64
+
+const (
+	// Transaction was sent to Mempool
+	MempoolAccepted MempoolStatusCode = 0
+	// Sequence number is old, etc.
+	MempoolInvalidSeqNumber MempoolStatusCode = 1
+	// Mempool is full (reached max global capacity)
+	MempoolIsFull MempoolStatusCode = 2
+	// Account reached max capacity per account
+	MempoolTooManyTransactions MempoolStatusCode = 3
+	// Invalid update. Only gas price increase is allowed
+	MempoolInvalidUpdate MempoolStatusCode = 4
+	// Transaction didn't pass vm_validation
+	MempoolVMError       MempoolStatusCode = 5
+	MempoolUnknownStatus MempoolStatusCode = 6
+)
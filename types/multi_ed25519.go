@@ -0,0 +1,276 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// MultiEd25519MaxKeys is the max number of public keys in a multi-signature public key.
+	MultiEd25519MaxKeys = 32
+
+	// MultiEd25519BitmapSize is the size of the bitmap in a multi-signature, in bytes.
+	MultiEd25519BitmapSize = 4
+)
+
+// MultiEd25519PublicKey is a K-of-N multi-signature public key, where N is the number of
+// public keys, and K is the threshold.
+//
+// It is serialized as the concatenation of the N public keys, followed by the threshold byte.
+type MultiEd25519PublicKey struct {
+	PublicKeys []ed25519.PublicKey
+	Threshold  uint8
+}
+
+// NewMultiEd25519PublicKey creates a K-of-N multi-signature public key. The order of
+// public keys matters.
+func NewMultiEd25519PublicKey(publicKeys []ed25519.PublicKey, threshold uint8) (*MultiEd25519PublicKey, error) {
+	k := &MultiEd25519PublicKey{Threshold: threshold}
+	for _, pk := range publicKeys {
+		k.PublicKeys = append(k.PublicKeys, append(ed25519.PublicKey(nil), pk...))
+	}
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// ParseMultiEd25519PublicKey parses a serialized multi-signature public key.
+func ParseMultiEd25519PublicKey(b []byte) (*MultiEd25519PublicKey, error) {
+	if len(b) == 0 || (len(b)-1)%ed25519.PublicKeySize != 0 {
+		return nil, fmt.Errorf("wrong multi-signature public key length %d", len(b))
+	}
+	n := (len(b) - 1) / ed25519.PublicKeySize
+	keys := make([]ed25519.PublicKey, n)
+	for i := range keys {
+		keys[i] = b[i*ed25519.PublicKeySize : (i+1)*ed25519.PublicKeySize]
+	}
+	return NewMultiEd25519PublicKey(keys, b[len(b)-1])
+}
+
+func (k *MultiEd25519PublicKey) validate() error {
+	n := len(k.PublicKeys)
+	if n == 0 || n > MultiEd25519MaxKeys {
+		return fmt.Errorf("number of public keys %d is not in [1, %d]", n, MultiEd25519MaxKeys)
+	}
+	if k.Threshold == 0 || int(k.Threshold) > n {
+		return fmt.Errorf("threshold %d is not in [1, %d]", k.Threshold, n)
+	}
+	for i, pk := range k.PublicKeys {
+		if len(pk) != ed25519.PublicKeySize {
+			return fmt.Errorf("wrong length of public key %d", i)
+		}
+	}
+	return nil
+}
+
+// Bytes serializes the multi-signature public key.
+func (k *MultiEd25519PublicKey) Bytes() []byte {
+	out := make([]byte, 0, len(k.PublicKeys)*ed25519.PublicKeySize+1)
+	for _, pk := range k.PublicKeys {
+		out = append(out, pk...)
+	}
+	return append(out, k.Threshold)
+}
+
+// AuthKey returns the authentication key of the multi-signature account.
+func (k *MultiEd25519PublicKey) AuthKey() AuthenticationKey {
+	return NewAuthenticationKey(k.Bytes(), MultiEd25519Scheme)
+}
+
+// Address returns the derived address of the multi-signature account.
+func (k *MultiEd25519PublicKey) Address() AccountAddress {
+	return k.AuthKey().DerivedAddress()
+}
+
+// Index returns the index of a public key in the multi-signature public key.
+func (k *MultiEd25519PublicKey) Index(publicKey ed25519.PublicKey) (int, bool) {
+	for i, pk := range k.PublicKeys {
+		if bytes.Equal(pk, publicKey) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Verify verifies a multi-signature of a message. It requires at least Threshold valid
+// signatures.
+func (k *MultiEd25519PublicKey) Verify(message []byte, sig *MultiEd25519Signature) error {
+	count := 0
+	for _, b := range sig.Bitmap {
+		count += bits.OnesCount8(b)
+	}
+	if count != len(sig.Signatures) {
+		return fmt.Errorf("bitmap has %d bits set, but there are %d signatures", count, len(sig.Signatures))
+	}
+	if count < int(k.Threshold) {
+		return fmt.Errorf("%d signatures are less than threshold %d", count, k.Threshold)
+	}
+	j := 0
+	for i := 0; i < MultiEd25519BitmapSize*8; i++ {
+		if !sig.bit(i) {
+			continue
+		}
+		if i >= len(k.PublicKeys) {
+			return fmt.Errorf("bitmap bit %d is out of range of %d public keys", i, len(k.PublicKeys))
+		}
+		if !ed25519.Verify(k.PublicKeys[i], message, sig.Signatures[j]) {
+			return fmt.Errorf("signature of public key %d verification fail", i)
+		}
+		j++
+	}
+	return nil
+}
+
+// MultiEd25519Signature is a multi-signature. The bitmap marks the indexes of public keys
+// which have signed, and the signatures are in the same order as the public keys.
+//
+// It is serialized as the concatenation of the signatures, followed by the bitmap.
+type MultiEd25519Signature struct {
+	Signatures [][]byte
+	Bitmap     [MultiEd25519BitmapSize]byte
+}
+
+// ParseMultiEd25519Signature parses a serialized multi-signature.
+func ParseMultiEd25519Signature(b []byte) (*MultiEd25519Signature, error) {
+	if len(b) < MultiEd25519BitmapSize || (len(b)-MultiEd25519BitmapSize)%ed25519.SignatureSize != 0 {
+		return nil, fmt.Errorf("wrong multi-signature length %d", len(b))
+	}
+	n := (len(b) - MultiEd25519BitmapSize) / ed25519.SignatureSize
+	if n == 0 || n > MultiEd25519MaxKeys {
+		return nil, fmt.Errorf("number of signatures %d is not in [1, %d]", n, MultiEd25519MaxKeys)
+	}
+	sig := &MultiEd25519Signature{}
+	for i := 0; i < n; i++ {
+		sig.Signatures = append(sig.Signatures, cloneBytes(b[i*ed25519.SignatureSize:(i+1)*ed25519.SignatureSize]))
+	}
+	copy(sig.Bitmap[:], b[len(b)-MultiEd25519BitmapSize:])
+	return sig, nil
+}
+
+// Bytes serializes the multi-signature.
+func (s *MultiEd25519Signature) Bytes() []byte {
+	out := make([]byte, 0, len(s.Signatures)*ed25519.SignatureSize+MultiEd25519BitmapSize)
+	for _, sig := range s.Signatures {
+		out = append(out, sig...)
+	}
+	return append(out, s.Bitmap[:]...)
+}
+
+func (s *MultiEd25519Signature) bit(i int) bool {
+	return s.Bitmap[i/8]&(128>>uint(i%8)) != 0
+}
+
+// PartialSignature is a signature of a raw transaction by one of the keys in a
+// multi-signature public key.
+type PartialSignature struct {
+	// Index is the index of the key in the multi-signature public key.
+	Index uint8
+
+	// Signature is the ed25519 signature of the raw transaction hash.
+	Signature []byte
+}
+
+// SignPartial signs the raw transaction with one of the keys in a multi-signature public key.
+// The partial signature should be added to a MultiSigCollector.
+func (rt *RawTransaction) SignPartial(publicKey *MultiEd25519PublicKey, signer Signer) (*PartialSignature, error) {
+	pk, ok := signer.Public().(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("signer public key is not ed25519 public key")
+	}
+	idx, ok := publicKey.Index(pk)
+	if !ok {
+		return nil, errors.New("signer is not in the multi-signature public key")
+	}
+	txnHash, err := rt.signingHash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signSigningHash(signer, txnHash)
+	if err != nil {
+		return nil, err
+	}
+	return &PartialSignature{Index: uint8(idx), Signature: sig}, nil
+}
+
+// MultiSigCollector collects partial signatures of a raw transaction from a multi-signature
+// account, and aggregates them into a signed transaction. It is not safe for concurrent use.
+type MultiSigCollector struct {
+	rawTxn    *RawTransaction
+	publicKey *MultiEd25519PublicKey
+	txnHash   []byte
+	sigs      map[uint8][]byte
+}
+
+// NewMultiSigCollector creates a collector of partial signatures of a raw transaction.
+func NewMultiSigCollector(rawTxn *RawTransaction, publicKey *MultiEd25519PublicKey) (*MultiSigCollector, error) {
+	if err := publicKey.validate(); err != nil {
+		return nil, err
+	}
+	txnHash, err := rawTxn.signingHash()
+	if err != nil {
+		return nil, err
+	}
+	return &MultiSigCollector{
+		rawTxn:    rawTxn,
+		publicKey: publicKey,
+		txnHash:   txnHash,
+		sigs:      make(map[uint8][]byte),
+	}, nil
+}
+
+// TxnHash returns the raw transaction hash to be signed, e.g. by an offline signer.
+func (c *MultiSigCollector) TxnHash() []byte {
+	return cloneBytes(c.txnHash)
+}
+
+// Add verifies a partial signature, and adds it to the collector. A later signature of
+// the same key replaces the earlier one.
+func (c *MultiSigCollector) Add(sig *PartialSignature) error {
+	if int(sig.Index) >= len(c.publicKey.PublicKeys) {
+		return fmt.Errorf("index %d is out of range of %d public keys", sig.Index, len(c.publicKey.PublicKeys))
+	}
+	if !ed25519.Verify(c.publicKey.PublicKeys[sig.Index], c.txnHash, sig.Signature) {
+		return fmt.Errorf("signature of public key %d verification fail", sig.Index)
+	}
+	c.sigs[sig.Index] = cloneBytes(sig.Signature)
+	return nil
+}
+
+// Count returns the number of distinct keys which have signed.
+func (c *MultiSigCollector) Count() int {
+	return len(c.sigs)
+}
+
+// Ready returns whether the number of signatures reaches the threshold.
+func (c *MultiSigCollector) Ready() bool {
+	return len(c.sigs) >= int(c.publicKey.Threshold)
+}
+
+// SignedTransaction aggregates the collected signatures into a signed transaction with
+// a MultiEd25519Authenticator. It fails if the threshold is not reached.
+func (c *MultiSigCollector) SignedTransaction() (*SignedTransaction, error) {
+	if !c.Ready() {
+		return nil, fmt.Errorf("%d signatures are less than threshold %d", len(c.sigs), c.publicKey.Threshold)
+	}
+	sig := &MultiEd25519Signature{}
+	for i := 0; i < len(c.publicKey.PublicKeys); i++ {
+		s, ok := c.sigs[uint8(i)]
+		if !ok {
+			continue
+		}
+		sig.Signatures = append(sig.Signatures, s)
+		sig.Bitmap[i/8] |= 128 >> uint(i%8)
+	}
+	return &SignedTransaction{
+		RawTxn: c.rawTxn,
+		Authenticator: &MultiEd25519Authenticator{
+			PublicKey: c.publicKey.Bytes(),
+			Signature: sig.Bytes(),
+		},
+	}, nil
+}
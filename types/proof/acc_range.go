@@ -53,6 +53,9 @@ func (r *AccumulatorRange) Verify(firstIndex uint64, hashes []HashValue, expecte
 		_, lBit := lastIter.Bit()
 
 		if fBit {
+			if len(leftSiblings) == 0 {
+				return errors.New("too few left siblings")
+			}
 			// prepend to hashes
 			hashes = append(hashes, nil)
 			copy(hashes[1:], hashes)
@@ -60,20 +63,24 @@ func (r *AccumulatorRange) Verify(firstIndex uint64, hashes []HashValue, expecte
 			leftSiblings = leftSiblings[1:]
 		}
 		if !lBit {
+			if len(rightSiblings) == 0 {
+				return errors.New("too few right siblings")
+			}
 			hashes = append(hashes, rightSiblings[0])
 			rightSiblings = rightSiblings[1:]
 		}
 
-		// in-place update new hashes from pairs of hashes
+		// update new hashes from pairs of hashes, without overwriting the siblings and
+		// the input hashes, which are owned by the caller
 		for i := 0; i < len(hashes)/2; i++ {
 			hasher.Reset()
 			hasher.Write(hashes[i*2])
 			hasher.Write(hashes[i*2+1])
-			hashes[i] = hasher.Sum(hashes[i][:0])
+			hashes[i] = hasher.Sum(nil)
 		}
 		hashes = hashes[:len(hashes)/2]
 
-		if len(leftSiblings) == 0 && len(rightSiblings) == 0 {
+		if len(hashes) == 1 && len(leftSiblings) == 0 && len(rightSiblings) == 0 {
 			break
 		}
 	}
@@ -3,6 +3,7 @@ package types
 import (
 	"crypto"
 	"crypto/rand"
+	"errors"
 	"fmt"
 
 	"github.com/the729/go-libra/crypto/sha3libra"
@@ -186,11 +187,15 @@ func (*TxnPayloadScript) EnumTypes() []lcs.EnumVariant {
 // Clone the transaction payload
 func (v *TxnPayloadScript) Clone() TransactionPayload {
 	c := cloneBytes(v.Code)
+	tyArgs := make([]TypeTag, 0, len(v.TyArgs))
+	for _, tyArg := range v.TyArgs {
+		tyArgs = append(tyArgs, tyArg.Clone())
+	}
 	args := make([]TransactionArgument, 0, len(v.Args))
 	for _, arg := range v.Args {
 		args = append(args, arg.Clone())
 	}
-	return &TxnPayloadScript{Code: c, Args: args}
+	return &TxnPayloadScript{Code: c, TyArgs: tyArgs, Args: args}
 }
 
 // TxnPayloadModule is variant of TransactionPayload
@@ -224,7 +229,7 @@ func (*RawTransaction) EnumTypes() []lcs.EnumVariant {
 
 // Clone the raw transaction
 func (rt *RawTransaction) Clone() *RawTransaction {
-	return &RawTransaction{
+	out := &RawTransaction{
 		Sender:         rt.Sender,
 		SequenceNumber: rt.SequenceNumber,
 		Payload:        rt.Payload.Clone(),
@@ -232,20 +237,46 @@ func (rt *RawTransaction) Clone() *RawTransaction {
 		GasUnitPrice:   rt.GasUnitPrice,
 		ExpirationTime: rt.ExpirationTime,
 	}
+	if rt.GasSpecifier != nil {
+		out.GasSpecifier = rt.GasSpecifier.Clone()
+	}
+	return out
 }
 
-// Sign the raw transaction with a private key.
-func (rt *RawTransaction) Sign(signer ed25519.PrivateKey) (*SignedTransaction, error) {
+// signingHash returns the hash of the raw transaction, which is signed by the sender.
+func (rt *RawTransaction) signingHash() ([]byte, error) {
 	hasher := sha3libra.NewRawTransaction()
 	if err := lcs.NewEncoder(hasher).Encode(rt); err != nil {
 		return nil, fmt.Errorf("raw transaction serialize error: %v", err)
 	}
-	txnHash := hasher.Sum([]byte{})
-	senderPubKey := signer.Public().(ed25519.PublicKey)
+	return hasher.Sum([]byte{}), nil
+}
+
+func signSigningHash(signer Signer, txnHash []byte) ([]byte, error) {
 	sig, err := signer.Sign(rand.Reader, txnHash, crypto.Hash(0))
 	if err != nil {
 		return nil, fmt.Errorf("sign transaction error: %v", err)
 	}
+	if len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("wrong signature length %d", len(sig))
+	}
+	return sig, nil
+}
+
+// Sign the raw transaction with a signer, e.g. an ed25519.PrivateKey.
+func (rt *RawTransaction) Sign(signer Signer) (*SignedTransaction, error) {
+	txnHash, err := rt.signingHash()
+	if err != nil {
+		return nil, err
+	}
+	senderPubKey, ok := signer.Public().(ed25519.PublicKey)
+	if !ok || len(senderPubKey) != ed25519.PublicKeySize {
+		return nil, errors.New("signer public key is not ed25519 public key")
+	}
+	sig, err := signSigningHash(signer, txnHash)
+	if err != nil {
+		return nil, err
+	}
 
 	return &SignedTransaction{
 		RawTxn: rt,
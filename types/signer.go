@@ -0,0 +1,19 @@
+package types
+
+import (
+	"crypto"
+	"io"
+)
+
+// Signer signs raw transactions on behalf of an account. The private key may live in
+// memory, or in an external key management system, HSM, or signing service.
+//
+// Signer has the same method set as crypto.Signer, so that ed25519.PrivateKey is a Signer.
+type Signer interface {
+	// Public returns the public key of the signer, which must be an ed25519.PublicKey.
+	Public() crypto.PublicKey
+
+	// Sign signs the hash of a raw transaction, and returns the ed25519 signature.
+	// Implementations may ignore rand and opts.
+	Sign(rand io.Reader, txnHash []byte, opts crypto.SignerOpts) (signature []byte, err error)
+}
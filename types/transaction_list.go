@@ -151,3 +151,25 @@ func (ptl *ProvenTransactionList) GetLedgerInfo() *ProvenLedgerInfo {
 	}
 	return ptl.ledgerInfo
 }
+
+// SubList returns the part of the proven transaction list, from version start with limit
+// transactions, which is proven by the same ledger info. It returns nil if the list does
+// not cover all these versions.
+func (ptl *ProvenTransactionList) SubList(start, limit uint64) *ProvenTransactionList {
+	if !ptl.proven {
+		panic("not valid proven transaction list")
+	}
+	if len(ptl.transactions) == 0 || limit == 0 || limit > uint64(len(ptl.transactions)) {
+		return nil
+	}
+	first := ptl.transactions[0].version
+	if start < first || start-first > uint64(len(ptl.transactions))-limit {
+		return nil
+	}
+	i := start - first
+	return &ProvenTransactionList{
+		proven:       true,
+		transactions: append([]*ProvenTransaction(nil), ptl.transactions[i:i+limit]...),
+		ledgerInfo:   ptl.ledgerInfo,
+	}
+}
@@ -14,9 +14,14 @@ type ED25519Authenticator struct {
 	Signature []byte
 }
 
-// type MultiED25519Authenticator struct {
+// MultiEd25519Authenticator is the authenticator of a multi-signature account.
+type MultiEd25519Authenticator struct {
+	// PublicKey is the serialized MultiEd25519PublicKey of the sender.
+	PublicKey []byte
 
-// }
+	// Signature is the serialized MultiEd25519Signature.
+	Signature []byte
+}
 
 // Clone the TxnAuthenticator
 func (v *ED25519Authenticator) Clone() TxnAuthenticator {
@@ -26,10 +31,23 @@ func (v *ED25519Authenticator) Clone() TxnAuthenticator {
 	return out
 }
 
+// Clone the TxnAuthenticator
+func (v *MultiEd25519Authenticator) Clone() TxnAuthenticator {
+	out := &MultiEd25519Authenticator{}
+	out.PublicKey = cloneBytes(v.PublicKey)
+	out.Signature = cloneBytes(v.Signature)
+	return out
+}
+
 var txnAuthenticatorEnumDef = []lcs.EnumVariant{
 	{
 		Name:     "TxnAuthenticator",
 		Value:    0,
 		Template: (*ED25519Authenticator)(nil),
 	},
+	{
+		Name:     "TxnAuthenticator",
+		Value:    1,
+		Template: (*MultiEd25519Authenticator)(nil),
+	},
 }
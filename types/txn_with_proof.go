@@ -29,8 +29,11 @@ func (t *TransactionWithProof) FromProto(pb *pbtypes.TransactionWithProof) error
 	}
 	t.RawTxn = pb.Transaction.Transaction
 
-	t.Events = make([]*ContractEvent, 0, len(pb.Events.Events))
-	for _, ev := range pb.Events.Events {
+	// leave Events nil if not fetched, so that it will not be verified
+	if pb.Events != nil {
+		t.Events = make(EventList, 0, len(pb.Events.Events))
+	}
+	for _, ev := range pb.GetEvents().GetEvents() {
 		ev1 := &ContractEvent{}
 		if err := ev1.FromProto(ev); err != nil {
 			return err
@@ -71,3 +74,17 @@ func (t *TransactionWithProof) Verify(ledgerInfo *ProvenLedgerInfo) (*ProvenTran
 	pTxn.ledgerInfo = ledgerInfo
 	return pTxn, nil
 }
+
+// VerifyWithEvents verifies the proof of the transaction like Verify, for a query which
+// fetched events. It fails if the events are withheld, i.e. no events are given, but the
+// event root hash is not the hash of an empty event list.
+func (t *TransactionWithProof) VerifyWithEvents(ledgerInfo *ProvenLedgerInfo) (*ProvenTransaction, error) {
+	pTxn, err := t.Verify(ledgerInfo)
+	if err != nil {
+		return nil, err
+	}
+	if !pTxn.withEvents {
+		return nil, fmt.Errorf("events withheld in txn(%d)", t.Version)
+	}
+	return pTxn, nil
+}
@@ -6,7 +6,6 @@ import (
 
 	"golang.org/x/crypto/ed25519"
 
-	"github.com/the729/go-libra/crypto/sha3libra"
 	"github.com/the729/go-libra/generated/pbtypes"
 	"github.com/the729/lcs"
 )
@@ -71,16 +70,33 @@ func (t *SignedTransaction) VerifySignature() error {
 	// }
 
 	// 2. verify signature
-	txnHasher := sha3libra.NewRawTransaction()
-	if err := lcs.NewEncoder(txnHasher).Encode(t.RawTxn); err != nil {
+	txnHash, err := t.RawTxn.signingHash()
+	if err != nil {
 		return fmt.Errorf("marshal raw txn error: %v", err)
 	}
-	txnHash := txnHasher.Sum([]byte{})
 
-	ea := t.Authenticator.(*ED25519Authenticator)
-	k := ed25519.PublicKey(ea.PublicKey)
-	if !ed25519.Verify(k, txnHash, ea.Signature) {
-		return errors.New("signature verification fail")
+	switch a := t.Authenticator.(type) {
+	case *ED25519Authenticator:
+		if len(a.PublicKey) != ed25519.PublicKeySize {
+			return errors.New("wrong public key length")
+		}
+		if !ed25519.Verify(ed25519.PublicKey(a.PublicKey), txnHash, a.Signature) {
+			return errors.New("signature verification fail")
+		}
+	case *MultiEd25519Authenticator:
+		pk, err := ParseMultiEd25519PublicKey(a.PublicKey)
+		if err != nil {
+			return err
+		}
+		sig, err := ParseMultiEd25519Signature(a.Signature)
+		if err != nil {
+			return err
+		}
+		if err := pk.Verify(txnHash, sig); err != nil {
+			return fmt.Errorf("multi-signature verification fail: %v", err)
+		}
+	default:
+		return errors.New("unknown authenticator type")
 	}
 
 	return nil
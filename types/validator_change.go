@@ -16,9 +16,9 @@ type ValidatorChangeProof struct {
 }
 
 type ProvenValidatorChange struct {
-	proven         bool
-	lastLedgerInfo *LedgerInfo
-	genesisHash    []byte
+	proven      bool
+	ledgerInfos []*LedgerInfo
+	genesisHash []byte
 }
 
 // FromProto parses a protobuf struct into this struct.
@@ -37,13 +37,21 @@ func (vcp *ValidatorChangeProof) FromProto(pb *pbtypes.ValidatorChangeProof) err
 }
 
 // Verify the ValidatorChangeProof, which is a series of LedgerInfo.
+//
+// Leading ledger infos of epochs which are already known by the verifier are skipped. This
+// happens when the proof is one of several pages, e.g. when More is true in an earlier proof.
 func (vcp *ValidatorChangeProof) Verify(v LedgerInfoVerifier) (*ProvenValidatorChange, error) {
 	if len(vcp.LedgerInfoWithSigs) == 0 {
 		return nil, errors.New("empty validator change")
 	}
 	var genesisHash []byte
+	var lastLedger0 *LedgerInfoWithSignaturesV0
+	var ledgerInfos []*LedgerInfo
 	for _, li := range vcp.LedgerInfoWithSigs {
 		li0 := li.Value.(*LedgerInfoWithSignaturesV0)
+		if lastLedger0 == nil && !v.EpochChangeVerificationRequired(li0.Epoch+1) {
+			continue
+		}
 		if err := v.Verify(li); err != nil {
 			return nil, fmt.Errorf("some ledger info failed to verify: %v", err)
 		}
@@ -58,12 +66,16 @@ func (vcp *ValidatorChangeProof) Verify(v LedgerInfoVerifier) (*ProvenValidatorC
 			return nil, fmt.Errorf("init new validator error: %v", err)
 		}
 		v = vv
+		lastLedger0 = li0
+		ledgerInfos = append(ledgerInfos, li0.LedgerInfo.Clone())
+	}
+	if lastLedger0 == nil {
+		return nil, ErrNoNewValidatorChange
 	}
-	lastLedger0 := vcp.LedgerInfoWithSigs[len(vcp.LedgerInfoWithSigs)-1].Value.(*LedgerInfoWithSignaturesV0)
 	return &ProvenValidatorChange{
-		proven:         true,
-		lastLedgerInfo: lastLedger0.LedgerInfo.Clone(),
-		genesisHash:    cloneBytes(genesisHash),
+		proven:      true,
+		ledgerInfos: ledgerInfos,
+		genesisHash: cloneBytes(genesisHash),
 	}, nil
 }
 
@@ -74,8 +86,24 @@ func (pvc *ProvenValidatorChange) GetLastLedgerInfo() *ProvenLedgerInfo {
 	}
 	return &ProvenLedgerInfo{
 		proven:     true,
-		ledgerInfo: pvc.lastLedgerInfo,
+		ledgerInfo: pvc.ledgerInfos[len(pvc.ledgerInfos)-1],
+	}
+}
+
+// GetLedgerInfos returns the ProvenLedgerInfos of all newly verified epoch changes, in
+// increasing epoch order. Skipped ledger infos of known epochs are not included.
+func (pvc *ProvenValidatorChange) GetLedgerInfos() []*ProvenLedgerInfo {
+	if !pvc.proven {
+		panic("not valid proven validator change")
+	}
+	plis := make([]*ProvenLedgerInfo, 0, len(pvc.ledgerInfos))
+	for _, li := range pvc.ledgerInfos {
+		plis = append(plis, &ProvenLedgerInfo{
+			proven:     true,
+			ledgerInfo: li,
+		})
 	}
+	return plis
 }
 
 // GetGenesisHash returns the genesis hash (if extracted from version 0)
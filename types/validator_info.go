@@ -36,8 +36,8 @@ type ValidatorInfo struct {
 	AccountAddress        AccountAddress   `toml:"addr" json:"addr"`
 	ConsensusPubkey       crypto.PublicKey `toml:"c" json:"c"`
 	ConsensusVotingPower  uint64           `toml:"power" json:"power"`
-	NetworkSigningPubkey  crypto.PublicKey `toml:"ns" json:"ns"`
-	NetworkIdentityPubkey crypto.PublicKey `toml:"ni" json:"ni"`
+	NetworkSigningPubkey  crypto.PublicKey `toml:"ns" json:"ns,omitempty"`
+	NetworkIdentityPubkey crypto.PublicKey `toml:"ni" json:"ni,omitempty"`
 }
 
 // FromProto parses a protobuf struct into this struct.
@@ -0,0 +1,289 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/the729/go-libra/internal/fileutil"
+	"github.com/the729/go-libra/signer"
+	"github.com/the729/go-libra/types"
+)
+
+// Keystore file format is versioned JSON. In version 1, the secrets are encrypted with
+// XChaCha20-Poly1305, keyed by Argon2id of the passphrase. The header, i.e. everything but
+// the ciphertext, is authenticated as additional data, so that KDF parameters cannot be
+// tampered with.
+//
+//   {
+//     "version": 1,
+//     "kdf": {"name": "argon2id", "salt": "...", "time": 1, "memory": 65536, "threads": 4},
+//     "cipher": {"name": "xchacha20-poly1305", "nonce": "..."},
+//     "ciphertext": "..."
+//   }
+
+// KeystoreVersion is the version of keystore files written by this package.
+const KeystoreVersion = 1
+
+const (
+	kdfArgon2id       = "argon2id"
+	cipherXChaCha20   = "xchacha20-poly1305"
+	keystoreSaltSize  = 16
+	keystoreMaxMemory = 4 * 1024 * 1024
+	keystoreMaxTime   = 32
+)
+
+// ErrWrongPassphrase is returned when a keystore cannot be decrypted, because the passphrase
+// is wrong, or the file is corrupted.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted keystore")
+
+// KDFParams are the Argon2id parameters of a keystore.
+type KDFParams struct {
+	// Time is the number of passes over the memory, at most 32.
+	Time uint32 `json:"time"`
+
+	// Memory is the size of memory in KiB, at most 4 GiB.
+	Memory uint32 `json:"memory"`
+
+	// Threads is the degree of parallelism.
+	Threads uint8 `json:"threads"`
+}
+
+// DefaultKDFParams returns the recommended Argon2id parameters: 1 pass, 64 MiB memory and
+// 4 threads.
+func DefaultKDFParams() *KDFParams {
+	return &KDFParams{
+		Time:    1,
+		Memory:  64 * 1024,
+		Threads: 4,
+	}
+}
+
+// Keystore is the decrypted content of a keystore file: an optional master seed with its
+// mnemonic, and the accounts in use.
+type Keystore struct {
+	// Mnemonic is the mnemonic of the master seed. It is empty if unknown.
+	Mnemonic string
+
+	// Seed is the master seed. It is nil if accounts are not derived from a seed.
+	Seed []byte
+
+	// Accounts are the accounts in the keystore.
+	Accounts []*Account
+}
+
+type keystoreHeader struct {
+	Version int `json:"version"`
+	KDF     struct {
+		Name string `json:"name"`
+		Salt string `json:"salt"`
+		KDFParams
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce string `json:"nonce"`
+	} `json:"cipher"`
+}
+
+type keystoreFile struct {
+	keystoreHeader
+	Ciphertext string `json:"ciphertext"`
+}
+
+type keystoreSecrets struct {
+	Mnemonic string             `json:"mnemonic,omitempty"`
+	Seed     string             `json:"seed,omitempty"`
+	Accounts []*keystoreAccount `json:"accounts"`
+}
+
+type keystoreAccount struct {
+	Index      *uint64 `json:"index,omitempty"`
+	PrivateKey string  `json:"private_key"`
+}
+
+// EncryptKeystore encrypts a keystore with a passphrase, and returns the keystore file
+// content. A nil params means DefaultKDFParams(). The child index of an account is saved
+// only if the account is derived from the seed at that index.
+func EncryptKeystore(ks *Keystore, passphrase []byte, params *KDFParams) ([]byte, error) {
+	if params == nil {
+		params = DefaultKDFParams()
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	var w *Wallet
+	if ks.Seed != nil || ks.Mnemonic != "" {
+		var err error
+		if w, err = ks.Wallet(); err != nil {
+			return nil, err
+		}
+	}
+	secrets := &keystoreSecrets{Mnemonic: ks.Mnemonic, Seed: hex.EncodeToString(ks.Seed)}
+	for _, a := range ks.Accounts {
+		sa := &keystoreAccount{PrivateKey: hex.EncodeToString(a.PrivateKey)}
+		// only accounts derived from the seed have a child index
+		if w != nil && w.Account(a.Index).Address == a.Address {
+			index := a.Index
+			sa.Index = &index
+		}
+		secrets.Accounts = append(secrets.Accounts, sa)
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, keystoreSaltSize)
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	f := &keystoreFile{}
+	f.Version = KeystoreVersion
+	f.KDF.Name = kdfArgon2id
+	f.KDF.Salt = hex.EncodeToString(salt)
+	f.KDF.KDFParams = *params
+	f.Cipher.Name = cipherXChaCha20
+	f.Cipher.Nonce = hex.EncodeToString(nonce)
+	ad, err := json.Marshal(&f.keystoreHeader)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(params.key(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+	f.Ciphertext = hex.EncodeToString(aead.Seal(nil, nonce, plaintext, ad))
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// DecryptKeystore decrypts the content of a keystore file with a passphrase.
+func DecryptKeystore(data, passphrase []byte) (*Keystore, error) {
+	f := &keystoreFile{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("keystore decode error: %v", err)
+	}
+	if f.Version != KeystoreVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d", f.Version)
+	}
+	if f.KDF.Name != kdfArgon2id || f.Cipher.Name != cipherXChaCha20 {
+		return nil, fmt.Errorf("unsupported keystore kdf %q or cipher %q", f.KDF.Name, f.Cipher.Name)
+	}
+	if err := f.KDF.KDFParams.validate(); err != nil {
+		return nil, err
+	}
+	salt, err1 := hex.DecodeString(f.KDF.Salt)
+	nonce, err2 := hex.DecodeString(f.Cipher.Nonce)
+	ciphertext, err3 := hex.DecodeString(f.Ciphertext)
+	if err1 != nil || err2 != nil || err3 != nil || len(nonce) != chacha20poly1305.NonceSizeX {
+		return nil, errors.New("keystore decode error: invalid salt, nonce or ciphertext")
+	}
+	ad, err := json.Marshal(&f.keystoreHeader)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(f.KDF.KDFParams.key(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	secrets := &keystoreSecrets{}
+	if err := json.Unmarshal(plaintext, secrets); err != nil {
+		return nil, fmt.Errorf("keystore secrets decode error: %v", err)
+	}
+	ks := &Keystore{Mnemonic: secrets.Mnemonic}
+	if secrets.Seed != "" {
+		if ks.Seed, err = hex.DecodeString(secrets.Seed); err != nil {
+			return nil, fmt.Errorf("keystore seed decode error: %v", err)
+		}
+	}
+	for _, sa := range secrets.Accounts {
+		prikey, err := hex.DecodeString(sa.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("keystore private key decode error: %v", err)
+		}
+		a, err := NewAccount(prikey)
+		if err != nil {
+			return nil, err
+		}
+		if sa.Index != nil {
+			a.Index = *sa.Index
+		}
+		ks.Accounts = append(ks.Accounts, a)
+	}
+	return ks, nil
+}
+
+// SaveKeystore encrypts a keystore, and writes it to a file atomically, readable only by
+// the owner. A nil params means DefaultKDFParams().
+func SaveKeystore(file string, ks *Keystore, passphrase []byte, params *KDFParams) error {
+	data, err := EncryptKeystore(ks, passphrase, params)
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(file, data)
+}
+
+// LoadKeystore reads a keystore file, and decrypts it with a passphrase.
+func LoadKeystore(file string, passphrase []byte) (*Keystore, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptKeystore(data, passphrase)
+}
+
+// Wallet returns the wallet of the mnemonic, or of the master seed if the mnemonic is
+// unknown. If both are set, the seed must be derived from the mnemonic.
+func (ks *Keystore) Wallet() (*Wallet, error) {
+	if ks.Mnemonic == "" {
+		if ks.Seed == nil {
+			return nil, errors.New("keystore has no seed")
+		}
+		return New(ks.Seed)
+	}
+	w, err := NewFromMnemonic(ks.Mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	if ks.Seed != nil && !bytes.Equal(ks.Seed, w.seed) {
+		return nil, errors.New("keystore seed does not match mnemonic")
+	}
+	return w, nil
+}
+
+// Signer returns an in-memory signer of an account in the keystore.
+func (ks *Keystore) Signer(addr types.AccountAddress) (types.Signer, error) {
+	for _, a := range ks.Accounts {
+		if a.Address == addr {
+			return signer.NewMemory(a.PrivateKey)
+		}
+	}
+	return nil, fmt.Errorf("account %x not in keystore", addr)
+}
+
+func (p *KDFParams) validate() error {
+	if p.Time == 0 || p.Time > keystoreMaxTime || p.Memory < 8*uint32(p.Threads) || p.Memory > keystoreMaxMemory || p.Threads == 0 {
+		return fmt.Errorf("invalid kdf params: time %d, memory %d KiB, threads %d", p.Time, p.Memory, p.Threads)
+	}
+	return nil
+}
+
+func (p *KDFParams) key(passphrase, salt []byte) []byte {
+	return argon2.IDKey(passphrase, salt, p.Time, p.Memory, p.Threads, chacha20poly1305.KeySize)
+}
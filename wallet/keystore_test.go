@@ -0,0 +1,87 @@
+package wallet_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/the729/go-libra/wallet"
+)
+
+func TestKeystore(t *testing.T) {
+	params := &wallet.KDFParams{Time: 1, Memory: 64, Threads: 1}
+	passphrase := []byte("correct horse battery staple")
+	w, err := wallet.Generate(nil)
+	require.NoError(t, err)
+	ks := &wallet.Keystore{
+		Mnemonic: w.Mnemonic(),
+		Seed:     w.Seed(),
+		Accounts: []*wallet.Account{w.Account(0), w.Account(7)},
+	}
+
+	t.Run("save and load", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "wallet.json")
+		require.NoError(t, wallet.SaveKeystore(file, ks, passphrase, params))
+		out, err := wallet.LoadKeystore(file, passphrase)
+		require.NoError(t, err)
+		assert.Equal(t, ks, out)
+
+		s, err := out.Signer(w.Account(7).Address)
+		require.NoError(t, err)
+		assert.Equal(t, w.Account(7).PublicKey, s.Public())
+		_, err = out.Signer(w.Account(1).Address)
+		assert.Error(t, err)
+
+		// the seed must match the mnemonic
+		_, err = wallet.EncryptKeystore(&wallet.Keystore{Mnemonic: w.Mnemonic(), Seed: make([]byte, wallet.SeedSize)}, passphrase, params)
+		assert.Error(t, err)
+	})
+
+	t.Run("imported keys", func(t *testing.T) {
+		_, prikey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		a, err := wallet.NewAccount(prikey)
+		require.NoError(t, err)
+		data, err := wallet.EncryptKeystore(&wallet.Keystore{Accounts: []*wallet.Account{a}}, passphrase, params)
+		require.NoError(t, err)
+		out, err := wallet.DecryptKeystore(data, passphrase)
+		require.NoError(t, err)
+		assert.Nil(t, out.Seed)
+		assert.Equal(t, []*wallet.Account{a}, out.Accounts)
+
+		// an imported key is not saved as a derived one
+		a.Index = 5
+		data, err = wallet.EncryptKeystore(&wallet.Keystore{Seed: w.Seed(), Accounts: []*wallet.Account{w.Account(3), a}}, passphrase, params)
+		require.NoError(t, err)
+		out, err = wallet.DecryptKeystore(data, passphrase)
+		require.NoError(t, err)
+		require.Len(t, out.Accounts, 2)
+		assert.Equal(t, uint64(3), out.Accounts[0].Index)
+		assert.Equal(t, uint64(0), out.Accounts[1].Index)
+		assert.Equal(t, a.Address, out.Accounts[1].Address)
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		data, err := wallet.EncryptKeystore(ks, passphrase, params)
+		require.NoError(t, err)
+		_, err = wallet.DecryptKeystore(data, []byte("wrong"))
+		assert.Equal(t, wallet.ErrWrongPassphrase, err)
+
+		// kdf params are authenticated
+		tampered := bytes.Replace(data, []byte(`"time": 1`), []byte(`"time": 2`), 1)
+		require.NotEqual(t, data, tampered)
+		_, err = wallet.DecryptKeystore(tampered, passphrase)
+		assert.Equal(t, wallet.ErrWrongPassphrase, err)
+
+		// costly kdf params are rejected before deriving the key
+		tampered = bytes.Replace(data, []byte(`"time": 1`), []byte(`"time": 4294967295`), 1)
+		require.NotEqual(t, data, tampered)
+		_, err = wallet.DecryptKeystore(tampered, passphrase)
+		assert.Error(t, err)
+		assert.NotEqual(t, wallet.ErrWrongPassphrase, err)
+	})
+}
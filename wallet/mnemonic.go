@@ -0,0 +1,122 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/sha3"
+)
+
+// Mnemonic encoding is the same as the reference Libra wallet. It follows BIP-0039 with the
+// English wordlist, except that the checksum is taken from SHA3-256 instead of SHA-256.
+// So mnemonics are not interchangeable with BIP-0039 wallets.
+//
+// Data of 16 to 32 bytes, in multiples of 4, is encoded with a checksum of len(data)/4 bits,
+// in 11-bit groups, each as a word. A wallet mnemonic encodes 32 bytes of entropy in 24 words,
+// and is stretched into the master seed by MnemonicToSeed.
+
+// DefaultMnemonicSalt is the salt used by the reference Libra wallet, and by NewFromMnemonic.
+const DefaultMnemonicSalt = "LIBRA"
+
+const (
+	mnemonicSaltPrefix = "LIBRA WALLET: mnemonic salt prefix$"
+	mnemonicIterations = 2048
+)
+
+var (
+	wordList  = strings.Fields(englishWords)
+	wordIndex = make(map[string]int, len(wordList))
+)
+
+func init() {
+	for i, w := range wordList {
+		wordIndex[w] = i
+	}
+}
+
+// EncodeMnemonic encodes data of 16 to 32 bytes, in multiples of 4, into a mnemonic.
+func EncodeMnemonic(data []byte) (string, error) {
+	if len(data) < 16 || len(data) > 32 || len(data)%4 != 0 {
+		return "", fmt.Errorf("wrong data length %d", len(data))
+	}
+	checksum := sha3.Sum256(data)
+	bits := append(append([]byte{}, data...), checksum[0])
+	nWords := (len(data)*8 + len(data)/4) / 11
+	words := make([]string, nWords)
+	for i := range words {
+		idx := 0
+		for j := i * 11; j < (i+1)*11; j++ {
+			idx = idx<<1 | int(bits[j/8]>>(7-uint(j%8))&1)
+		}
+		words[i] = wordList[idx]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// DecodeMnemonic decodes a mnemonic into data, and verifies the checksum.
+// Words are separated by white spaces, and are case insensitive.
+func DecodeMnemonic(mnemonic string) ([]byte, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, fmt.Errorf("wrong number of words %d", len(words))
+	}
+	nBits := len(words) * 11
+	nChecksumBits := nBits / 33
+	bits := make([]byte, (nBits+7)/8)
+	for i, w := range words {
+		idx, ok := wordIndex[w]
+		if !ok {
+			return nil, fmt.Errorf("unknown word %q", w)
+		}
+		for j := 0; j < 11; j++ {
+			if idx&(1<<uint(10-j)) != 0 {
+				k := i*11 + j
+				bits[k/8] |= 1 << (7 - uint(k%8))
+			}
+		}
+	}
+	data := bits[:(nBits-nChecksumBits)/8]
+	checksum := sha3.Sum256(data)
+	mask := byte(0xff) << uint(8-nChecksumBits)
+	if bits[len(data)]&mask != checksum[0]&mask {
+		return nil, errors.New("mnemonic checksum mismatch")
+	}
+	return data, nil
+}
+
+// MnemonicToSeed verifies a mnemonic, and derives the master seed of SeedSize bytes from it
+// with PBKDF2-HMAC-SHA3-256. The salt is appended to the reference salt prefix.
+func MnemonicToSeed(mnemonic, salt string) ([]byte, error) {
+	if _, err := DecodeMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	m := normalizeMnemonic(mnemonic)
+	return pbkdf2.Key([]byte(m), []byte(mnemonicSaltPrefix+salt), mnemonicIterations, SeedSize, sha3.New256), nil
+}
+
+// NewFromMnemonic creates a wallet from a mnemonic, with DefaultMnemonicSalt. The wallet
+// recovers the same accounts as the reference Libra wallet.
+func NewFromMnemonic(mnemonic string) (*Wallet, error) {
+	seed, err := MnemonicToSeed(mnemonic, DefaultMnemonicSalt)
+	if err != nil {
+		return nil, err
+	}
+	w, err := New(seed)
+	if err != nil {
+		return nil, err
+	}
+	w.mnemonic = normalizeMnemonic(mnemonic)
+	return w, nil
+}
+
+// Mnemonic returns the mnemonic of the wallet. It is empty if the wallet is created by New
+// from a seed, since the seed cannot be turned back into its mnemonic. Keep it secret.
+func (w *Wallet) Mnemonic() string {
+	return w.mnemonic
+}
+
+func normalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+}
@@ -0,0 +1,79 @@
+package wallet_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/the729/go-libra/wallet"
+)
+
+func TestMnemonic(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		for _, n := range []int{16, 20, 24, 28, 32} {
+			data := bytes.Repeat([]byte{0x5a}, n)
+			m, err := wallet.EncodeMnemonic(data)
+			require.NoError(t, err)
+			assert.Len(t, strings.Fields(m), n*3/4)
+			out, err := wallet.DecodeMnemonic(strings.ToUpper(m))
+			require.NoError(t, err)
+			assert.Equal(t, data, out)
+		}
+		_, err := wallet.EncodeMnemonic(make([]byte, 15))
+		assert.Error(t, err)
+	})
+
+	t.Run("wallet", func(t *testing.T) {
+		w, err := wallet.Generate(nil)
+		require.NoError(t, err)
+		m := w.Mnemonic()
+		assert.Len(t, strings.Fields(m), 24)
+		w2, err := wallet.NewFromMnemonic(m)
+		require.NoError(t, err)
+		assert.Equal(t, w.Account(3), w2.Account(3))
+	})
+
+	t.Run("seed", func(t *testing.T) {
+		m := "abandon amount liar amount expire adjust cage candy arch gather drum bullet " +
+			"absurd math era live bid rhythm alien crouch range attend journey thing"
+		data, err := wallet.DecodeMnemonic(m)
+		require.NoError(t, err)
+		assert.Equal(t, "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", hex.EncodeToString(data))
+
+		seed, err := wallet.MnemonicToSeed(m, wallet.DefaultMnemonicSalt)
+		require.NoError(t, err)
+		assert.Equal(t, "7dbc9ca7ea58c1f0337ae3c6ac3646f2dddd2035910086d2707fa7d38130698708e61556202cb38e9222064e42375c47193215f97ab77ef5b4f5cc99bbf6ff01", hex.EncodeToString(seed))
+		seed2, err := wallet.MnemonicToSeed(m, "other")
+		require.NoError(t, err)
+		assert.NotEqual(t, seed, seed2)
+
+		w, err := wallet.NewFromMnemonic("  " + strings.ToUpper(m) + "\n")
+		require.NoError(t, err)
+		assert.Equal(t, m, w.Mnemonic())
+		assert.Equal(t, seed, w.Seed())
+
+		w, err = wallet.New(seed)
+		require.NoError(t, err)
+		assert.Empty(t, w.Mnemonic())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		m, err := wallet.EncodeMnemonic(make([]byte, 32))
+		require.NoError(t, err)
+		words := strings.Fields(m)
+		assert.Equal(t, "abandon", words[0])
+
+		words[0] = "ability"
+		_, err = wallet.DecodeMnemonic(strings.Join(words, " "))
+		assert.Error(t, err)
+		words[0] = "notaword"
+		_, err = wallet.DecodeMnemonic(strings.Join(words, " "))
+		assert.Error(t, err)
+		_, err = wallet.DecodeMnemonic(strings.Join(words[:23], " "))
+		assert.Error(t, err)
+	})
+}
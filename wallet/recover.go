@@ -0,0 +1,64 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/types"
+)
+
+// DefaultGapLimit is the default number of consecutive unused child indexes, after which
+// Recover stops scanning.
+const DefaultGapLimit = 10
+
+// RecoveredAccount is an account found on the ledger by Recover.
+type RecoveredAccount struct {
+	*Account
+
+	// State is the proven state of the account.
+	State *types.ProvenAccountState
+}
+
+// Recover scans derived accounts from index 0, and returns those which exist on the ledger,
+// with their proven states. It stops after gapLimit consecutive indexes whose accounts do
+// not exist. A gapLimit of 0 means DefaultGapLimit.
+//
+// Accounts are queried gapLimit at a time in a batch, so each batch of states is a
+// consistent snapshot of the ledger.
+func (w *Wallet) Recover(ctx context.Context, c *client.Client, gapLimit int) ([]*RecoveredAccount, error) {
+	if gapLimit <= 0 {
+		gapLimit = DefaultGapLimit
+	}
+	var out []*RecoveredAccount
+	var index uint64
+	gap := 0
+	for gap < gapLimit {
+		b := c.NewBatch()
+		accounts := make([]*Account, gapLimit)
+		results := make([]*client.BatchAccountState, gapLimit)
+		for i := range accounts {
+			accounts[i] = w.Account(index + uint64(i))
+			results[i] = b.QueryAccountState(accounts[i].Address)
+		}
+		if _, err := b.Execute(ctx); err != nil {
+			return nil, err
+		}
+		for i, r := range results {
+			if r.Err != nil {
+				return nil, fmt.Errorf("query account %d error: %v", accounts[i].Index, r.Err)
+			}
+			if r.State.IsNil() {
+				gap++
+				if gap >= gapLimit {
+					break
+				}
+				continue
+			}
+			gap = 0
+			out = append(out, &RecoveredAccount{Account: accounts[i], State: r.State})
+		}
+		index += uint64(gapLimit)
+	}
+	return out, nil
+}
@@ -0,0 +1,131 @@
+/*
+Package wallet implements a hierarchical deterministic wallet, which derives ed25519 keys of
+accounts from a single master seed, with the key derivation scheme of the reference Libra wallet.
+
+The master key is HKDF-Extract(SHA3-256, salt "LIBRA WALLET: master key salt$", seed). The private
+key of child index i is HKDF-Expand(SHA3-256, master key, "LIBRA WALLET: derived key$" || i),
+where i is encoded as 8-byte little endian.
+
+The seed is derived from a mnemonic, as in the reference Libra wallet: PBKDF2-HMAC-SHA3-256 of
+the mnemonic, with salt "LIBRA WALLET: mnemonic salt prefix$" || salt, 2048 iterations. Backing up
+the mnemonic, or the seed, is enough to recover all accounts of the wallet.
+*/
+package wallet
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/the729/go-libra/types"
+)
+
+// SeedSize is the size of a master seed, in bytes.
+const SeedSize = 64
+
+var (
+	masterKeySalt = []byte("LIBRA WALLET: master key salt$")
+	infoPrefix    = []byte("LIBRA WALLET: derived key$")
+)
+
+// Wallet derives child keys from a master seed. It is safe for concurrent use.
+type Wallet struct {
+	mnemonic string
+	seed     []byte
+	master   []byte
+}
+
+// New creates a wallet from a master seed of SeedSize bytes.
+func New(seed []byte) (*Wallet, error) {
+	if len(seed) != SeedSize {
+		return nil, fmt.Errorf("wrong seed length %d", len(seed))
+	}
+	return &Wallet{
+		seed:   append([]byte(nil), seed...),
+		master: hkdf.Extract(sha3.New256, seed, masterKeySalt),
+	}, nil
+}
+
+// Generate creates a wallet from a new random mnemonic of 24 words.
+// If r is nil, crypto/rand.Reader is used.
+func Generate(r io.Reader) (*Wallet, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	entropy := make([]byte, 32)
+	if _, err := io.ReadFull(r, entropy); err != nil {
+		return nil, err
+	}
+	m, err := EncodeMnemonic(entropy)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromMnemonic(m)
+}
+
+// Seed returns a copy of the master seed. Keep it secret.
+func (w *Wallet) Seed() []byte {
+	return append([]byte(nil), w.seed...)
+}
+
+// PrivateKey derives the private key of a child index.
+func (w *Wallet) PrivateKey(index uint64) ed25519.PrivateKey {
+	info := make([]byte, len(infoPrefix)+8)
+	copy(info, infoPrefix)
+	binary.LittleEndian.PutUint64(info[len(infoPrefix):], index)
+	keySeed := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(hkdf.Expand(sha3.New256, w.master, info), keySeed); err != nil {
+		// HKDF-SHA3-256 can output up to 255*32 bytes.
+		panic(err)
+	}
+	return ed25519.NewKeyFromSeed(keySeed)
+}
+
+// Account derives the account of a child index.
+func (w *Wallet) Account(index uint64) *Account {
+	a := newAccount(w.PrivateKey(index))
+	a.Index = index
+	return a
+}
+
+// NewAccount creates an account from a private key, which is not derived from a seed.
+func NewAccount(privateKey ed25519.PrivateKey) (*Account, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("wrong private key length %d", len(privateKey))
+	}
+	return newAccount(append(ed25519.PrivateKey(nil), privateKey...)), nil
+}
+
+func newAccount(prikey ed25519.PrivateKey) *Account {
+	pubkey := prikey.Public().(ed25519.PublicKey)
+	authKey := types.NewAuthenticationKey(pubkey, types.Ed25519Scheme)
+	return &Account{
+		PrivateKey: prikey,
+		PublicKey:  pubkey,
+		AuthKey:    authKey,
+		Address:    authKey.DerivedAddress(),
+	}
+}
+
+// Account is an account of a wallet, either derived from the seed, or imported.
+type Account struct {
+	// Index is the child index of the account. It is 0 for accounts created by NewAccount.
+	Index uint64
+
+	// PrivateKey is the private key, which is also a types.Signer.
+	PrivateKey ed25519.PrivateKey
+
+	// PublicKey is the public key.
+	PublicKey ed25519.PublicKey
+
+	// AuthKey is the authentication key.
+	AuthKey types.AuthenticationKey
+
+	// Address is the account address, which is derived from the authentication key.
+	Address types.AccountAddress
+}
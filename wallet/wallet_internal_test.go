@@ -0,0 +1,21 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveVectors(t *testing.T) {
+	// the seed of the mnemonic in TestMnemonic/seed
+	seed, err := hex.DecodeString("7dbc9ca7ea58c1f0337ae3c6ac3646f2dddd2035910086d2707fa7d38130698708e61556202cb38e9222064e42375c47193215f97ab77ef5b4f5cc99bbf6ff01")
+	require.NoError(t, err)
+	w, err := New(seed)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1b525b325c7e590027a2ab538379eb9d4e1fc88300c30c5b13a247c2993a7813", hex.EncodeToString(w.master))
+	assert.Equal(t, "ea4a96b1fccc7e666668319752b22529e444794877384133a2dfdbf425554140", hex.EncodeToString(w.PrivateKey(0).Seed()))
+	assert.Equal(t, "66b1e4d8faf9896c5006fe28cb6018ad65ba362d4403fad828997ee3eef25801", hex.EncodeToString(w.PrivateKey(1).Seed()))
+}
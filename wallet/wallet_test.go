@@ -0,0 +1,70 @@
+package wallet_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/the729/go-libra/client"
+	"github.com/the729/go-libra/libratest"
+	"github.com/the729/go-libra/types"
+	"github.com/the729/go-libra/wallet"
+)
+
+func TestDerive(t *testing.T) {
+	seed := bytes.Repeat([]byte{1}, wallet.SeedSize)
+	w1, err := wallet.New(seed)
+	require.NoError(t, err)
+	w2, err := wallet.New(seed)
+	require.NoError(t, err)
+
+	a0 := w1.Account(0)
+	assert.Equal(t, a0, w2.Account(0))
+	assert.NotEqual(t, a0.Address, w1.Account(1).Address)
+
+	authKey := sha3.Sum256(append(append([]byte{}, a0.PublicKey...), 0))
+	assert.Equal(t, types.AuthenticationKey(authKey), a0.AuthKey)
+	assert.Equal(t, authKey[16:], a0.Address[:])
+
+	_, err = wallet.New(seed[1:])
+	assert.Error(t, err)
+}
+
+func TestRecover(t *testing.T) {
+	ctx := context.Background()
+	w, err := wallet.Generate(nil)
+	require.NoError(t, err)
+
+	l := libratest.NewLedger(4)
+	for _, idx := range []uint64{0, 1, 5, 16} {
+		a := w.Account(idx)
+		require.NoError(t, l.CreateAccount(a.Address, a.AuthKey[:], 1000+idx))
+	}
+	s := libratest.NewServer(l)
+	addr, err := s.Start()
+	require.NoError(t, err)
+	defer s.Stop()
+	c, err := client.New(addr, l.Waypoint())
+	require.NoError(t, err)
+	defer c.Close()
+
+	// 16 is beyond a gap of 10 after 5
+	accounts, err := w.Recover(ctx, c, 0)
+	require.NoError(t, err)
+	var indexes []uint64
+	for _, a := range accounts {
+		indexes = append(indexes, a.Index)
+		br, err := a.State.GetAccountBlob().GetLibraBalanceResource()
+		require.NoError(t, err)
+		assert.Equal(t, 1000+a.Index, br.Coin)
+	}
+	assert.Equal(t, []uint64{0, 1, 5}, indexes)
+
+	accounts, err = w.Recover(ctx, c, 11)
+	require.NoError(t, err)
+	assert.Len(t, accounts, 4)
+}